@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenTTL время жизни токена (совпадает со сроком cookie во фронтенде)
+const TokenTTL = 8 * time.Hour
+
+// SharedUserID идентификатор пользователя, которому принадлежат задачи
+// при входе по общему паролю TODO_PASSWORD и при отключённой аутентификации.
+const SharedUserID int64 = 0
+
+// Режимы регистрации пользователей
+const (
+	RegistrationPassword = "password" // при заданном общем пароле регистрирует только вошедший по нему
+	RegistrationOpen     = "open"     // регистрироваться может любой
+	RegistrationClosed   = "closed"   // регистрация отключена
+)
+
+// RegistrationModes допустимые режимы регистрации
+var RegistrationModes = []string{RegistrationPassword, RegistrationOpen, RegistrationClosed}
+
+// ErrPasswordChanged возвращается, если токен выдан до смены пароля.
+var ErrPasswordChanged = errors.New("token was issued for another password")
+
+// Claims содержимое токена. PasswordHash позволяет отозвать все
+// выданные пользователю токены сменой пароля. Это HMAC пароля на ключе подписи,
+// поэтому подобрать пароль по токену без ключа нельзя.
+type Claims struct {
+	UserID       int64  `json:"uid"`
+	PasswordHash string `json:"pwd"`
+	jwt.RegisteredClaims
+}
+
+// Auth проверяет общий пароль и выдаёт подписанные токены.
+type Auth struct {
+	password string
+	secret   []byte
+}
+
+// New создаёт Auth для общего пароля и ключа подписи токенов.
+func New(password, secret string) *Auth {
+	return &Auth{password: password, secret: []byte(secret)}
+}
+
+// Enabled сообщает, требуется ли вход для доступа к задачам (задан ли общий пароль).
+func (a *Auth) Enabled() bool {
+	return a.password != ""
+}
+
+// CheckPassword сравнивает пароль с общим за постоянное время.
+func (a *Auth) CheckPassword(password string) bool {
+	return subtle.ConstantTimeCompare([]byte(password), []byte(a.password)) == 1
+}
+
+// SharedToken выдаёт токен для входа по общему паролю.
+func (a *Auth) SharedToken() (string, error) {
+	return a.NewToken(SharedUserID, a.password)
+}
+
+// NewToken выдаёт пользователю токен, подписанный HMAC-SHA256.
+// password - пароль или его хеш из базы, при изменении которого токен перестаёт действовать.
+func (a *Auth) NewToken(userID int64, password string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:       userID,
+		PasswordHash: a.Fingerprint(password),
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TokenTTL)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(a.secret)
+}
+
+// Parse проверяет подпись и срок действия токена и возвращает его содержимое.
+// Для токенов общего пароля также проверяется, что пароль не менялся.
+func (a *Auth) Parse(tokenString string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (any, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	if claims.UserID == SharedUserID && !a.Matches(&claims, a.password) {
+		return nil, ErrPasswordChanged
+	}
+	return &claims, nil
+}
+
+// Matches проверяет, что токен выдан для указанного пароля.
+func (a *Auth) Matches(claims *Claims, password string) bool {
+	return hmac.Equal([]byte(claims.PasswordHash), []byte(a.Fingerprint(password)))
+}
+
+// Fingerprint возвращает HMAC-SHA256 пароля на ключе подписи для хранения в токене.
+func (a *Auth) Fingerprint(password string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
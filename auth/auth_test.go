@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedToken(t *testing.T) {
+	a := New("secret", "signing-key")
+	assert.True(t, a.Enabled())
+	assert.True(t, a.CheckPassword("secret"))
+	assert.False(t, a.CheckPassword("Secret"))
+	assert.False(t, New("", "signing-key").Enabled())
+
+	token, err := a.SharedToken()
+	require.NoError(t, err)
+	claims, err := a.Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, SharedUserID, claims.UserID)
+
+	// По токену нельзя подобрать пароль без ключа подписи
+	sum := sha256.Sum256([]byte("secret"))
+	assert.NotEqual(t, hex.EncodeToString(sum[:]), claims.PasswordHash)
+	assert.NotEqual(t, New("secret", "other-key").Fingerprint("secret"), claims.PasswordHash)
+}
+
+func TestPasswordChanged(t *testing.T) {
+	token, err := New("secret", "signing-key").SharedToken()
+	require.NoError(t, err)
+
+	_, err = New("new-secret", "signing-key").Parse(token)
+	assert.ErrorIs(t, err, ErrPasswordChanged)
+
+	// Токен пользователя отзывается сменой хеша его пароля
+	a := New("", "signing-key")
+	token, err = a.NewToken(7, "hash-1")
+	require.NoError(t, err)
+	claims, err := a.Parse(token)
+	require.NoError(t, err)
+	assert.True(t, a.Matches(claims, "hash-1"))
+	assert.False(t, a.Matches(claims, "hash-2"))
+}
+
+func TestParseRejects(t *testing.T) {
+	a := New("secret", "signing-key")
+	token, err := a.SharedToken()
+	require.NoError(t, err)
+
+	_, err = New("secret", "other-key").Parse(token)
+	assert.Error(t, err)
+	_, err = a.Parse(token + "x")
+	assert.Error(t, err)
+
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		PasswordHash: a.Fingerprint("secret"),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	})
+	signed, err := expired.SignedString([]byte("signing-key"))
+	require.NoError(t, err)
+	_, err = a.Parse(signed)
+	assert.Error(t, err)
+}
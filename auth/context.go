@@ -0,0 +1,19 @@
+package auth
+
+import "context"
+
+type contextKey struct{}
+
+// WithUserID сохраняет идентификатор пользователя в контексте запроса.
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserID возвращает идентификатор пользователя из контекста запроса.
+// Если пользователь не задан, возвращается SharedUserID.
+func UserID(ctx context.Context) int64 {
+	if userID, ok := ctx.Value(contextKey{}).(int64); ok {
+		return userID
+	}
+	return SharedUserID
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"go_final_project/config"
+	"go_final_project/db"
+)
+
+// runBackup выполняет подкоманду backup [FILE]: сохраняет копию базы SQLite
+// в FILE (по умолчанию scheduler-YYYYMMDD-HHMMSS.db). Сервер можно не останавливать.
+func runBackup(cfg *config.Config, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("expected at most one file name, got %d", len(args))
+	}
+	path := "scheduler-" + time.Now().Format("20060102-150405") + ".db"
+	if len(args) == 1 {
+		path = args[0]
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("file %s already exists", path)
+	}
+
+	// Open создаёт отсутствующий файл SQLite, а копировать пустую базу бессмысленно
+	if cfg.DatabaseURL == "" {
+		if _, err := os.Stat(cfg.DBFile); err != nil {
+			return err
+		}
+	}
+
+	store, err := db.Open(cfg.DatabaseURL, cfg.DBFile)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := store.Backup(context.Background(), path); err != nil {
+		return err
+	}
+	slog.Info("database saved", "path", path)
+	return nil
+}
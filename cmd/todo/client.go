@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// requestTimeout ограничивает время запроса к серверу
+const requestTimeout = 30 * time.Second
+
+// Client вызывает HTTP API планировщика
+type Client struct {
+	Server string
+	Token  string // передаётся в cookie token, как это делает веб-интерфейс
+	Lang   string // язык сообщений сервера в заголовке Accept-Language, пусто - язык сервера
+	HTTP   *http.Client
+}
+
+// NewClient создаёт клиента для сервера server
+func NewClient(server, token string) *Client {
+	return &Client{
+		Server: strings.TrimSuffix(server, "/"),
+		Token:  token,
+		HTTP:   &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Do выполняет запрос к path с параметрами query и телом body (nil - без тела)
+// и возвращает тело ответа. Ответ с полем error или кодом не 2xx возвращается как ошибка.
+func (c *Client) Do(method, path string, query url.Values, body any) ([]byte, error) {
+	target := c.Server + "/" + strings.TrimPrefix(path, "/")
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Lang != "" {
+		req.Header.Set("Accept-Language", c.Lang)
+	}
+	if c.Token != "" {
+		req.AddCookie(&http.Cookie{Name: "token", Value: c.Token})
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiError struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &apiError) == nil && apiError.Error != "" {
+		return nil, fmt.Errorf("%s", apiError.Error)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	return data, nil
+}
+
+// DoJSON выполняет запрос и разбирает ответ в out
+func (c *Client) DoJSON(method, path string, query url.Values, body, out any) ([]byte, error) {
+	data, err := c.Do(method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("invalid server response: %w", err)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_final_project/constants"
+	"go_final_project/models"
+)
+
+// command выполняет команду с аргументами args, флаги команды объявляются в set
+type command func(a *app, set *flag.FlagSet, args []string) error
+
+var commands = map[string]command{
+	"add":       cmdAdd,
+	"list":      cmdList,
+	"search":    cmdSearch,
+	"show":      cmdShow,
+	"edit":      cmdEdit,
+	"done":      cmdDone,
+	"delete":    cmdDelete,
+	"next-date": cmdNextDate,
+	"login":     cmdLogin,
+}
+
+// taskFlags флаги полей задачи для add и edit
+type taskFlags struct {
+	title, date, comment, repeat, time, project, tags *string
+	priority                                          *int
+}
+
+func addTaskFlags(set *flag.FlagSet, withTitle bool) *taskFlags {
+	f := &taskFlags{
+		date:     set.String("date", "", "date YYYYMMDD"),
+		comment:  set.String("comment", "", "comment"),
+		repeat:   set.String("repeat", "", "repeat rule (d 7, y, w 1,3, m 1,-1)"),
+		priority: set.Int("priority", 0, "priority 0-3"),
+		time:     set.String("time", "", "time HH:MM"),
+		project:  set.String("project", "", "project"),
+		tags:     set.String("tags", "", "comma-separated tags"),
+	}
+	if withTitle {
+		f.title = set.String("title", "", "title")
+	}
+	return f
+}
+
+// apply переносит в task значения флагов, указанных в командной строке
+func (f *taskFlags) apply(set *flag.FlagSet, task *models.Task) {
+	set.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "title":
+			task.Title = *f.title
+		case "date":
+			task.Date = *f.date
+		case "comment":
+			task.Comment = *f.comment
+		case "repeat":
+			task.Repeat = *f.repeat
+		case "priority":
+			task.Priority = *f.priority
+		case "time":
+			task.Time = *f.time
+		case "project":
+			task.Project = *f.project
+		case "tags":
+			task.Tags = splitTags(*f.tags)
+		}
+	})
+}
+
+func splitTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// taskID возвращает единственный аргумент команды - ID задачи
+func taskID(set *flag.FlagSet) (string, error) {
+	if set.NArg() != 1 {
+		return "", fmt.Errorf("%s: expected task ID", set.Name())
+	}
+	id := set.Arg(0)
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return "", fmt.Errorf("%s: invalid task ID: %s", set.Name(), id)
+	}
+	return id, nil
+}
+
+func cmdAdd(a *app, set *flag.FlagSet, args []string) error {
+	f := addTaskFlags(set, false)
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	task := models.Task{Title: strings.Join(set.Args(), " ")}
+	if task.Title == "" {
+		return errors.New("add: expected task title")
+	}
+	f.apply(set, &task)
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	data, err := a.client.DoJSON(http.MethodPost, "api/task", nil, task, &resp)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printRaw(data)
+	}
+	fmt.Fprintln(a.stdout, resp.ID)
+	return nil
+}
+
+func cmdList(a *app, set *flag.FlagSet, args []string) error {
+	project := set.String("project", "", "only tasks of the project")
+	tag := set.String("tag", "", "only tasks with all comma-separated tags")
+	limit := set.Int("limit", 0, "maximum number of tasks (0 - all)")
+	cursor := set.String("cursor", "", "continue after the page that returned the cursor")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() > 0 {
+		return errors.New("list: unexpected arguments, use search to filter by text")
+	}
+
+	query := url.Values{}
+	if *project != "" {
+		query.Set("project", *project)
+	}
+	for _, t := range splitTags(*tag) {
+		query.Add("tag", t)
+	}
+	if *cursor != "" {
+		query.Set("cursor", *cursor)
+	}
+	return a.listTasks(query, *limit)
+}
+
+func cmdSearch(a *app, set *flag.FlagSet, args []string) error {
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	search := strings.Join(set.Args(), " ")
+	if search == "" {
+		return errors.New("search: expected text or date DD.MM.YYYY")
+	}
+	return a.listTasks(url.Values{"search": {search}}, 0)
+}
+
+// taskList ответ сервера со страницей задач
+type taskList struct {
+	Tasks      []models.Task `json:"tasks"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// listTasks запрашивает страницы задач по курсору next_cursor, пока не наберёт
+// limit задач (0 - все). Курсор оставшихся задач выводится, чтобы продолжить через -cursor.
+func (a *app) listTasks(query url.Values, limit int) error {
+	list := taskList{Tasks: []models.Task{}}
+	for {
+		if limit > 0 {
+			query.Set("limit", strconv.Itoa(limit-len(list.Tasks)))
+		}
+		var page taskList
+		if _, err := a.client.DoJSON(http.MethodGet, "api/tasks", query, nil, &page); err != nil {
+			return err
+		}
+		list.Tasks = append(list.Tasks, page.Tasks...)
+		list.NextCursor = page.NextCursor
+		if list.NextCursor == "" || limit > 0 && len(list.Tasks) >= limit {
+			break
+		}
+		query.Set("cursor", list.NextCursor)
+	}
+	if a.json {
+		return a.printJSON(list)
+	}
+	if err := printTasks(a.stdout, list.Tasks); err != nil {
+		return err
+	}
+	if list.NextCursor != "" {
+		fmt.Fprintln(a.stderr, "more tasks: todo list -cursor", list.NextCursor)
+	}
+	return nil
+}
+
+func cmdShow(a *app, set *flag.FlagSet, args []string) error {
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := taskID(set)
+	if err != nil {
+		return err
+	}
+
+	var task models.Task
+	data, err := a.client.DoJSON(http.MethodGet, "api/task", url.Values{"id": {id}}, nil, &task)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printRaw(data)
+	}
+	return printTask(a.stdout, task)
+}
+
+func cmdEdit(a *app, set *flag.FlagSet, args []string) error {
+	f := addTaskFlags(set, true)
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := taskID(set)
+	if err != nil {
+		return err
+	}
+	if set.NFlag() == 0 {
+		return errors.New("edit: nothing to change")
+	}
+
+	// Сервер заменяет задачу целиком, поэтому неуказанные поля берутся из текущей
+	var task models.Task
+	if _, err := a.client.DoJSON(http.MethodGet, "api/task", url.Values{"id": {id}}, nil, &task); err != nil {
+		return err
+	}
+	f.apply(set, &task)
+	if task.Tags == nil {
+		task.Tags = []string{}
+	}
+
+	// Пустые необязательные поля передаются явно, иначе сервер оставит прежние значения
+	body := map[string]any{
+		"id":       task.ID,
+		"date":     task.Date,
+		"title":    task.Title,
+		"comment":  task.Comment,
+		"repeat":   task.Repeat,
+		"priority": task.Priority,
+		"time":     task.Time,
+		"project":  task.Project,
+		"tags":     task.Tags,
+	}
+	// С версией сервер не перезапишет изменения, сделанные после чтения задачи
+	if task.Version != 0 {
+		body["version"] = strconv.FormatInt(task.Version, 10)
+	}
+	data, err := a.client.Do(http.MethodPut, "api/task", nil, body)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printRaw(data)
+	}
+	return nil
+}
+
+func cmdDone(a *app, set *flag.FlagSet, args []string) error {
+	return a.taskAction(set, args, http.MethodPost, "api/task/done")
+}
+
+func cmdDelete(a *app, set *flag.FlagSet, args []string) error {
+	return a.taskAction(set, args, http.MethodDelete, "api/task")
+}
+
+// taskAction выполняет запрос без тела к задаче с ID из аргументов
+func (a *app) taskAction(set *flag.FlagSet, args []string, method, path string) error {
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := taskID(set)
+	if err != nil {
+		return err
+	}
+
+	data, err := a.client.Do(method, path, url.Values{"id": {id}}, nil)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printRaw(data)
+	}
+	return nil
+}
+
+func cmdNextDate(a *app, set *flag.FlagSet, args []string) error {
+	now := set.String("now", time.Now().Format(constants.DateFormat), "current date YYYYMMDD")
+	date := set.String("date", "", "task date YYYYMMDD")
+	repeat := set.String("repeat", "", "repeat rule")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if *date == "" || *repeat == "" {
+		return errors.New("next-date: -date and -repeat are required")
+	}
+
+	data, err := a.client.Do(http.MethodGet, "api/nextdate",
+		url.Values{"now": {*now}, "date": {*date}, "repeat": {*repeat}}, nil)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(map[string]string{"date": string(data)})
+	}
+	fmt.Fprintln(a.stdout, string(data))
+	return nil
+}
+
+func cmdLogin(a *app, set *flag.FlagSet, args []string) error {
+	login := set.String("login", "", "user login (empty - shared password)")
+	save := set.Bool("save", true, "save server URL and token to the config file")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	password, err := a.readPassword()
+	if err != nil {
+		return err
+	}
+
+	path, body := "api/signin", map[string]string{"password": password}
+	if *login != "" {
+		path, body["login"] = "api/login", *login
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if _, err := a.client.DoJSON(http.MethodPost, path, nil, body, &resp); err != nil {
+		return err
+	}
+	if *save {
+		a.config.Server, a.config.Token = a.client.Server, resp.Token
+		if err := a.config.Save(); err != nil {
+			return err
+		}
+	}
+	if a.json {
+		return a.printJSON(resp)
+	}
+	fmt.Fprintln(a.stdout, resp.Token)
+	return nil
+}
+
+// readPassword берёт пароль из TODO_PASSWORD или первой строки стандартного ввода.
+// Флага для пароля нет: аргументы команды видны другим пользователям в списке процессов.
+func (a *app) readPassword() (string, error) {
+	if password := os.Getenv("TODO_PASSWORD"); password != "" {
+		return password, nil
+	}
+	// Приглашение нужно только при вводе с терминала, а не из канала или файла
+	if file, ok := a.stdin.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprint(a.stderr, "Password: ")
+		}
+	}
+	line, err := bufio.NewReader(a.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("login: expected password on stdin or in TODO_PASSWORD")
+	}
+	return password, nil
+}
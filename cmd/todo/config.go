@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultServer адрес сервера по умолчанию
+const DefaultServer = "http://localhost:7540"
+
+// Config настройки клиента
+type Config struct {
+	Server string `json:"server,omitempty"`
+	Token  string `json:"token,omitempty"`
+}
+
+// ConfigPath возвращает путь к файлу настроек: TODO_CONFIG или todo/config.json
+// в каталоге настроек пользователя.
+func ConfigPath() (string, error) {
+	if path := os.Getenv("TODO_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "todo", "config.json"), nil
+}
+
+// LoadConfig читает файл настроек, если он есть, и применяет поверх него
+// переменные окружения TODO_SERVER и TODO_TOKEN.
+func LoadConfig() (*Config, error) {
+	config := &Config{}
+	path, err := ConfigPath()
+	if err == nil {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, config); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if server := os.Getenv("TODO_SERVER"); server != "" {
+		config.Server = server
+	}
+	if token := os.Getenv("TODO_TOKEN"); token != "" {
+		config.Token = token
+	}
+	if config.Server == "" {
+		config.Server = DefaultServer
+	}
+	return config, nil
+}
+
+// Save записывает настройки в файл, доступный только владельцу
+func (c *Config) Save() error {
+	path, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,97 @@
+// Команда todo - клиент API планировщика для терминала.
+//
+//	todo [-server URL] [-token TOKEN] [-json] <команда> [флаги] [аргументы]
+//
+// Адрес сервера и токен берутся из флагов, переменных окружения TODO_SERVER
+// и TODO_TOKEN или файла настроек, который сохраняет команда login.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: todo [-server URL] [-token TOKEN] [-json] <command> [flags] [args]
+
+Commands:
+  add       add a task:              todo add [-date D] [-repeat R] ... TITLE
+  list      list upcoming tasks:     todo list [-project P] [-tag T] [-limit N] [-cursor C]
+  search    search tasks:            todo search TEXT | DD.MM.YYYY
+  show      show a task:             todo show ID
+  edit      change task fields:      todo edit [-title T] [-date D] ... ID
+  done      mark a task as done:     todo done ID
+  delete    move a task to trash:    todo delete ID
+  next-date calculate next date:     todo next-date [-now D] -date D -repeat R
+  login     get and save a token:    todo login [-login L] < password (or TODO_PASSWORD)
+
+Global flags:
+`
+
+// app параметры запуска, общие для всех команд
+type app struct {
+	client *Client
+	json   bool // выводить ответы сервера в формате JSON
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	config *Config
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "todo:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run разбирает общие флаги и выполняет команду
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("todo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	server := flags.String("server", config.Server, "server URL (TODO_SERVER)")
+	token := flags.String("token", config.Token, "authentication token (TODO_TOKEN)")
+	lang := flags.String("lang", os.Getenv("TODO_LANG"), "language of server messages: ru or en (TODO_LANG)")
+	asJSON := flags.Bool("json", false, "print server responses as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	client := NewClient(*server, *token)
+	client.Lang = *lang
+	a := &app{
+		client: client,
+		json:   *asJSON,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+		config: config,
+	}
+	command, commandArgs := flags.Arg(0), flags.Args()[1:]
+	cmd, ok := commands[command]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("unknown command: %s", command)
+	}
+
+	set := flag.NewFlagSet("todo "+command, flag.ContinueOnError)
+	set.SetOutput(stderr)
+	return cmd(a, set, commandArgs)
+}
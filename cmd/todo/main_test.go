@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/models"
+)
+
+// fakeServer отвечает заготовленными ответами и запоминает последний запрос
+type fakeServer struct {
+	responses map[string]string // "METHOD /path" -> тело ответа
+	method    string
+	query     string
+	body      map[string]any
+	token     string
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.method, f.query, f.body = r.Method, r.URL.RawQuery, nil
+	if cookie, err := r.Cookie("token"); err == nil {
+		f.token = cookie.Value
+	}
+	data, _ := io.ReadAll(r.Body)
+	json.Unmarshal(data, &f.body)
+
+	response, ok := f.responses[r.Method+" "+r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	io.WriteString(w, response)
+}
+
+func runCLI(t *testing.T, server *httptest.Server, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv("TODO_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	t.Setenv("TODO_SERVER", server.URL)
+	t.Setenv("TODO_TOKEN", "")
+
+	var stdout, stderr bytes.Buffer
+	err := run(args, strings.NewReader(""), &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestAdd(t *testing.T) {
+	fake := &fakeServer{responses: map[string]string{"POST /api/task": `{"id":"12"}`}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	out, err := runCLI(t, server, "-token", "secret", "add", "-date", "20240115", "-tags", "дом, срочно", "Купить", "молоко")
+	require.NoError(t, err)
+	assert.Equal(t, "12\n", out)
+	assert.Equal(t, "secret", fake.token)
+	assert.Equal(t, "Купить молоко", fake.body["title"])
+	assert.Equal(t, "20240115", fake.body["date"])
+	assert.Equal(t, []any{"дом", "срочно"}, fake.body["tags"])
+
+	_, err = runCLI(t, server, "add")
+	assert.Error(t, err)
+}
+
+func TestListAndSearch(t *testing.T) {
+	fake := &fakeServer{responses: map[string]string{"GET /api/tasks": `{"tasks":[
+		{"id":"1","date":"20240115","title":"Отчёт","repeat":"d 7","priority":2,"time":"09:30","project":"Работа","tags":["офис"]}
+	]}`}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	out, err := runCLI(t, server, "list", "-project", "Работа", "-tag", "офис,срочно")
+	require.NoError(t, err)
+	assert.Equal(t, "project=%D0%A0%D0%B0%D0%B1%D0%BE%D1%82%D0%B0&tag=%D0%BE%D1%84%D0%B8%D1%81&tag=%D1%81%D1%80%D0%BE%D1%87%D0%BD%D0%BE", fake.query)
+	assert.Contains(t, out, "ID")
+	assert.Contains(t, out, "15.01.2024")
+	assert.Contains(t, out, "Отчёт")
+	assert.Contains(t, out, "!!")
+
+	out, err = runCLI(t, server, "-json", "search", "Отчёт")
+	require.NoError(t, err)
+	assert.Equal(t, "search=%D0%9E%D1%82%D1%87%D1%91%D1%82", fake.query)
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(out), &resp))
+	assert.Len(t, resp["tasks"], 1)
+}
+
+func TestEdit(t *testing.T) {
+	fake := &fakeServer{responses: map[string]string{
+		"GET /api/task": `{"id":"3","date":"20240115","title":"Отчёт","priority":2,"time":"09:30","tags":["офис"],"version":"4"}`,
+		"PUT /api/task": `{}`,
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	_, err := runCLI(t, server, "edit", "-title", "Квартальный отчёт", "-time", "", "-tags", "", "3")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, fake.method)
+	assert.Equal(t, "3", fake.body["id"])
+	assert.Equal(t, "Квартальный отчёт", fake.body["title"])
+	assert.Equal(t, "20240115", fake.body["date"])
+	assert.Equal(t, float64(2), fake.body["priority"])
+	assert.Equal(t, "", fake.body["time"])
+	assert.Equal(t, []any{}, fake.body["tags"])
+	assert.Equal(t, "4", fake.body["version"])
+
+	_, err = runCLI(t, server, "edit", "3")
+	assert.Error(t, err)
+	_, err = runCLI(t, server, "edit", "-title", "x", "abc")
+	assert.Error(t, err)
+}
+
+func TestErrors(t *testing.T) {
+	fake := &fakeServer{responses: map[string]string{
+		"POST /api/task/done": `{"error":"Ошибка при получении задачи"}`,
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	_, err := runCLI(t, server, "done", "5")
+	assert.EqualError(t, err, "Ошибка при получении задачи")
+	assert.Equal(t, "id=5", fake.query)
+
+	_, err = runCLI(t, server, "delete", "5")
+	assert.ErrorContains(t, err, "404")
+
+	_, err = runCLI(t, server, "unknown")
+	assert.ErrorContains(t, err, "unknown command")
+}
+
+func TestLoginSavesConfig(t *testing.T) {
+	fake := &fakeServer{responses: map[string]string{"POST /api/login": `{"token":"abc"}`}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("TODO_CONFIG", path)
+	t.Setenv("TODO_SERVER", "")
+	t.Setenv("TODO_TOKEN", "")
+	var stdout bytes.Buffer
+	require.NoError(t, run([]string{"-server", server.URL, "login", "-login", "anna"},
+		strings.NewReader("12345678\n"), &stdout, io.Discard))
+	assert.Equal(t, "anna", fake.body["login"])
+	assert.Equal(t, "12345678", fake.body["password"])
+	assert.Equal(t, "abc\n", stdout.String())
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, server.URL, config.Server)
+	assert.Equal(t, "abc", config.Token)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestLoginPassword(t *testing.T) {
+	fake := &fakeServer{responses: map[string]string{"POST /api/signin": `{"token":"abc"}`}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	// Пароль во флаге был бы виден в списке процессов
+	_, err := runCLI(t, server, "login", "-save=false", "-password", "secret")
+	assert.Error(t, err)
+
+	t.Setenv("TODO_PASSWORD", "secret")
+	out, err := runCLI(t, server, "login", "-save=false")
+	require.NoError(t, err)
+	assert.Equal(t, "abc\n", out)
+	assert.Equal(t, "secret", fake.body["password"])
+
+	t.Setenv("TODO_PASSWORD", "")
+	_, err = runCLI(t, server, "login", "-save=false")
+	assert.ErrorContains(t, err, "TODO_PASSWORD")
+}
+
+func TestListPages(t *testing.T) {
+	// Сервер отдаёт не больше limit задач (по умолчанию две), курсор - ID последней задачи страницы
+	titles := []string{"Первая", "Вторая", "Третья", "Четвёртая", "Пятая"}
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		from, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		limit := 2
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, _ = strconv.Atoi(value)
+		}
+		to := min(from+limit, len(titles))
+		var page taskList
+		for i := from; i < to; i++ {
+			page.Tasks = append(page.Tasks, models.Task{ID: strconv.Itoa(i + 1), Date: "20240115", Title: titles[i]})
+		}
+		if to < len(titles) {
+			page.NextCursor = strconv.Itoa(to)
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	out, err := runCLI(t, server, "-json", "list")
+	require.NoError(t, err)
+	var resp taskList
+	require.NoError(t, json.Unmarshal([]byte(out), &resp))
+	assert.Len(t, resp.Tasks, 5)
+	assert.Empty(t, resp.NextCursor)
+	assert.Equal(t, []string{"", "cursor=2", "cursor=4"}, queries)
+
+	// С лимитом выводится курсор, с которого можно продолжить
+	queries = nil
+	out, err = runCLI(t, server, "-json", "list", "-limit", "3")
+	require.NoError(t, err)
+	resp = taskList{}
+	require.NoError(t, json.Unmarshal([]byte(out), &resp))
+	assert.Len(t, resp.Tasks, 3)
+	assert.Equal(t, "3", resp.NextCursor)
+	assert.Equal(t, []string{"limit=3"}, queries)
+
+	queries = nil
+	out, err = runCLI(t, server, "list", "-cursor", "3")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Четвёртая")
+	assert.Contains(t, out, "Пятая")
+	assert.NotContains(t, out, "Третья")
+	assert.Equal(t, []string{"cursor=3"}, queries)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go_final_project/constants"
+	"go_final_project/models"
+)
+
+// displayDateFormat формат даты в таблицах
+const displayDateFormat = "02.01.2006"
+
+// printRaw выводит ответ сервера как есть
+func (a *app) printRaw(data []byte) error {
+	_, err := fmt.Fprintln(a.stdout, strings.TrimSpace(string(data)))
+	return err
+}
+
+// printJSON выводит value в формате JSON
+func (a *app) printJSON(value any) error {
+	encoder := json.NewEncoder(a.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// printTasks выводит задачи таблицей
+func printTasks(w io.Writer, tasks []models.Task) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tTIME\tPRI\tTITLE\tPROJECT\tTAGS\tREPEAT")
+	for _, task := range tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			task.ID, displayDate(task.Date), task.Time, priority(task.Priority),
+			task.Title, task.Project, strings.Join(task.Tags, ","), repeatText(task))
+	}
+	return tw.Flush()
+}
+
+// printTask выводит поля задачи, пропуская пустые
+func printTask(w io.Writer, task models.Task) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", name, value)
+		}
+	}
+	field("ID", task.ID)
+	field("Title", task.Title)
+	field("Date", displayDate(task.Date))
+	field("Time", task.Time)
+	field("Priority", priority(task.Priority))
+	if task.RepeatText != "" {
+		field("Repeat", task.Repeat+" ("+task.RepeatText+")")
+	} else {
+		field("Repeat", task.Repeat)
+	}
+	field("Project", task.Project)
+	field("Tags", strings.Join(task.Tags, ", "))
+	field("Comment", task.Comment)
+	return tw.Flush()
+}
+
+// repeatText возвращает описание правила повторения от сервера, а если его нет - само правило
+func repeatText(task models.Task) string {
+	if task.RepeatText != "" {
+		return task.RepeatText
+	}
+	return task.Repeat
+}
+
+func displayDate(date string) string {
+	parsed, err := time.Parse(constants.DateFormat, date)
+	if err != nil {
+		return date
+	}
+	return parsed.Format(displayDateFormat)
+}
+
+func priority(p int) string {
+	if p == 0 {
+		return ""
+	}
+	return strings.Repeat("!", p)
+}
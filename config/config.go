@@ -0,0 +1,298 @@
+// Package config собирает настройки сервера из значений по умолчанию,
+// файла настроек, переменных окружения и флагов командной строки.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+
+	"go_final_project/auth"
+	"go_final_project/i18n"
+	"go_final_project/webhooks"
+)
+
+// ConfigFileEnv переменная окружения с путём к файлу настроек
+const ConfigFileEnv = "TODO_CONFIG_FILE"
+
+// LogLevels допустимые уровни журнала в порядке возрастания важности
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// LogFormats допустимые форматы журнала
+var LogFormats = []string{"text", "json"}
+
+// Config настройки сервера. Значение берётся из первого источника, где оно задано:
+// флаг, переменная окружения, файл настроек (JSON), значение по умолчанию.
+type Config struct {
+	Port            int    `json:"port"`
+	DBFile          string `json:"db_file"`
+	DatabaseURL     string `json:"database_url"`
+	WebDir          string `json:"web_dir"`
+	Password        string `json:"password"`
+	Registration    string `json:"registration"` // auth.RegistrationPassword, Open или Closed
+	JWTSecret       string `json:"jwt_secret"`
+	LogLevel        string `json:"log_level"`
+	LogFormat       string `json:"log_format"`
+	Lang            string `json:"lang"` // язык ответов, если клиент его не указал
+	TLSCert         string `json:"tls_cert"`
+	TLSKey          string `json:"tls_key"`
+	TrashDays       int    `json:"trash_days"`       // 0 - не удалять задачи из корзины
+	RemindBefore    int    `json:"remind_before"`    // в минутах
+	ShutdownTimeout int    `json:"shutdown_timeout"` // в секундах
+	WebhookAllow    string `json:"webhook_allow"`    // внутренние подсети через запятую, куда разрешены вебхуки
+	WebhookDays     int    `json:"webhook_days"`     // 0 - не удалять доставленные события вебхуков
+
+	// Каналы напоминаний, пустой адрес или токен - канал не используется
+	SMTPAddr         string `json:"smtp_addr"` // host:port
+	SMTPFrom         string `json:"smtp_from"`
+	SMTPTo           string `json:"smtp_to"` // адреса через запятую
+	SMTPUser         string `json:"smtp_user"`
+	SMTPPassword     string `json:"smtp_password"`
+	NotifyWebhookURL string `json:"notify_webhook_url"`
+	TelegramToken    string `json:"telegram_token"`
+	TelegramChatID   string `json:"telegram_chat_id"`
+	TelegramAPIURL   string `json:"telegram_api_url"` // пусто - notify.DefaultTelegramAPI
+}
+
+// Default возвращает настройки по умолчанию
+func Default() *Config {
+	return &Config{
+		Port:            7540,
+		DBFile:          "scheduler.db",
+		WebDir:          "./web",
+		LogLevel:        "info",
+		LogFormat:       "text",
+		Lang:            i18n.RU,
+		Registration:    auth.RegistrationPassword,
+		TrashDays:       30,
+		WebhookDays:     7,
+		RemindBefore:    15,
+		ShutdownTimeout: 15,
+	}
+}
+
+// option описывает настройку: имя флага, переменную окружения и поле Config.
+// flag == "" - настройку нельзя задать флагом (пароли не должны попадать в список процессов).
+type option struct {
+	flag, env, usage string
+	value            any // *string или *int
+}
+
+func (c *Config) options() []option {
+	return []option{
+		{"port", "TODO_PORT", "HTTP port", &c.Port},
+		{"db", "TODO_DBFILE", "SQLite database file", &c.DBFile},
+		{"database-url", "TODO_DATABASE_URL", "PostgreSQL connection URL (overrides -db)", &c.DatabaseURL},
+		{"web", "TODO_WEB_DIR", "directory with frontend files", &c.WebDir},
+		{"", "TODO_PASSWORD", "", &c.Password},
+		{"", "TODO_JWT_SECRET", "", &c.JWTSecret},
+		{"registration", "TODO_REGISTRATION", "who may register users: password, open or closed", &c.Registration},
+		{"log-level", "TODO_LOG_LEVEL", "log level: debug, info, warn or error", &c.LogLevel},
+		{"log-format", "TODO_LOG_FORMAT", "log format: text or json", &c.LogFormat},
+		{"lang", "TODO_LANG", "default language of API messages: ru or en", &c.Lang},
+		{"tls-cert", "TODO_TLS_CERT", "TLS certificate file", &c.TLSCert},
+		{"tls-key", "TODO_TLS_KEY", "TLS private key file", &c.TLSKey},
+		{"trash-days", "TODO_TRASH_DAYS", "days to keep deleted tasks, 0 - forever", &c.TrashDays},
+		{"remind-before", "TODO_REMIND_BEFORE", "minutes before task time to send a reminder", &c.RemindBefore},
+		{"shutdown-timeout", "TODO_SHUTDOWN_TIMEOUT", "seconds to finish requests and background jobs on stop", &c.ShutdownTimeout},
+		{"webhook-allow", "TODO_WEBHOOK_ALLOW", "comma-separated internal networks webhooks may target, e.g. 10.0.0.0/8", &c.WebhookAllow},
+		{"webhook-days", "TODO_WEBHOOK_DAYS", "days to keep delivered webhook events, 0 - forever", &c.WebhookDays},
+		{"smtp-addr", "TODO_SMTP_ADDR", "SMTP server host:port for reminders", &c.SMTPAddr},
+		{"smtp-from", "TODO_SMTP_FROM", "sender address of reminder emails", &c.SMTPFrom},
+		{"smtp-to", "TODO_SMTP_TO", "comma-separated recipients of reminder emails", &c.SMTPTo},
+		{"smtp-user", "TODO_SMTP_USER", "SMTP login", &c.SMTPUser},
+		{"", "TODO_SMTP_PASSWORD", "", &c.SMTPPassword},
+		{"notify-webhook-url", "TODO_NOTIFY_WEBHOOK_URL", "URL that receives reminders as JSON", &c.NotifyWebhookURL},
+		{"", "TODO_TELEGRAM_TOKEN", "", &c.TelegramToken},
+		{"telegram-chat-id", "TODO_TELEGRAM_CHAT_ID", "chat that receives reminders from the bot", &c.TelegramChatID},
+		{"telegram-api-url", "TODO_TELEGRAM_API_URL", "bot API server", &c.TelegramAPIURL},
+	}
+}
+
+// set разбирает значение настройки из строки
+func (o option) set(value string) error {
+	switch v := o.value.(type) {
+	case *string:
+		*v = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*v = n
+	}
+	return nil
+}
+
+// Load объявляет флаги настроек в fs (и флаг -config), разбирает args
+// и собирает настройки. Оставшиеся аргументы доступны через fs.Args().
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	c := Default()
+	options := c.options()
+
+	configFile := fs.String("config", "", "configuration file (JSON, "+ConfigFileEnv+")")
+	flags := map[string]string{}
+	for _, o := range options {
+		if o.flag == "" {
+			continue
+		}
+		name, usage := o.flag, o.usage+" ("+o.env
+		if value := fmt.Sprint(deref(o.value)); value != "" {
+			usage += ", default " + value
+		}
+		fs.Func(name, usage+")", func(value string) error {
+			flags[name] = value
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	path := *configFile
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path != "" {
+		if err := c.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, o := range options {
+		if value, ok := os.LookupEnv(o.env); ok && value != "" {
+			if err := o.set(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", o.env, err)
+			}
+		}
+	}
+	for _, o := range options {
+		if value, ok := flags[o.flag]; ok && o.flag != "" {
+			if err := o.set(value); err != nil {
+				return nil, fmt.Errorf("-%s: %w", o.flag, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// readFile читает настройки из JSON-файла. Неизвестные ключи считаются ошибкой,
+// чтобы опечатка в имени настройки не проходила незамеченной.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate проверяет согласованность настроек и наличие указанных файлов
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Port <= 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.DatabaseURL == "" && c.DBFile == "" {
+		errs = append(errs, errors.New("database file is not set"))
+	}
+	if info, err := os.Stat(c.WebDir); err != nil || !info.IsDir() {
+		errs = append(errs, fmt.Errorf("web directory %q does not exist", c.WebDir))
+	}
+	if !slices.Contains(LogLevels, c.LogLevel) {
+		errs = append(errs, fmt.Errorf("unknown log level %q", c.LogLevel))
+	}
+	if !slices.Contains(LogFormats, c.LogFormat) {
+		errs = append(errs, fmt.Errorf("unknown log format %q", c.LogFormat))
+	}
+	if !slices.Contains(auth.RegistrationModes, c.Registration) {
+		errs = append(errs, fmt.Errorf("unknown registration mode %q", c.Registration))
+	}
+	if !i18n.Supported(c.Lang) {
+		errs = append(errs, fmt.Errorf("unsupported language %q", c.Lang))
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, errors.New("TLS requires both certificate and key"))
+	}
+	for _, file := range []string{c.TLSCert, c.TLSKey} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.TrashDays < 0 {
+		errs = append(errs, fmt.Errorf("trash days must not be negative, got %d", c.TrashDays))
+	}
+	if c.WebhookDays < 0 {
+		errs = append(errs, fmt.Errorf("webhook days must not be negative, got %d", c.WebhookDays))
+	}
+	if c.RemindBefore < 0 {
+		errs = append(errs, fmt.Errorf("remind before must not be negative, got %d", c.RemindBefore))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout must be positive, got %d", c.ShutdownTimeout))
+	}
+	if _, err := webhooks.ParseNetworks(c.WebhookAllow); err != nil {
+		errs = append(errs, err)
+	}
+	if c.SMTPAddr != "" && (c.SMTPFrom == "" || c.SMTPTo == "") {
+		errs = append(errs, errors.New("smtp reminders require sender and recipient addresses"))
+	}
+	if c.TelegramToken != "" && c.TelegramChatID == "" {
+		errs = append(errs, errors.New("telegram reminders require a chat ID"))
+	}
+	return errors.Join(errs...)
+}
+
+// TLS проверяет, нужно ли запускать сервер по HTTPS
+func (c *Config) TLS() bool {
+	return c.TLSCert != "" && c.TLSKey != ""
+}
+
+// Redacted возвращает копию настроек со скрытыми паролями, ключом подписи,
+// токеном бота и паролем в строке подключения, пригодную для вывода.
+func (c *Config) Redacted() Config {
+	redacted := *c
+	hide := func(s *string) {
+		if *s != "" {
+			*s = "***"
+		}
+	}
+	hide(&redacted.Password)
+	hide(&redacted.JWTSecret)
+	hide(&redacted.SMTPPassword)
+	hide(&redacted.TelegramToken)
+	redacted.DatabaseURL = redactURL(redacted.DatabaseURL)
+	return redacted
+}
+
+func deref(value any) any {
+	switch v := value.(type) {
+	case *string:
+		return *v
+	case *int:
+		return *v
+	}
+	return nil
+}
+
+// redactURL скрывает пароль в строке подключения
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
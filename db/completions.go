@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"go_final_project/models"
+)
+
+// ErrNothingToUndo возвращается, если у задачи нет выполнения, которое можно отменить.
+var ErrNothingToUndo = errors.New("no completion to undo")
+
+// CompleteTask отмечает задачу выполненной: сохраняет запись в истории и удаляет
+// разовую задачу (nextDate пустая) или переносит повторяющуюся на nextDate.
+func (s *SQLStore) CompleteTask(ctx context.Context, userID int64, task models.Task, nextDate string) (*models.Completion, error) {
+	id, err := strconv.ParseInt(task.ID, 10, 64)
+	if err != nil {
+		return nil, ErrTaskNotFound
+	}
+
+	completion := models.Completion{
+		TaskID:      task.ID,
+		Title:       task.Title,
+		Comment:     task.Comment,
+		Repeat:      task.Repeat,
+		Date:        task.Date,
+		NextDate:    nextDate,
+		Priority:    task.Priority,
+		Time:        task.Time,
+		Project:     task.Project,
+		Tags:        task.Tags,
+		CompletedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	err = s.inTx(ctx, func(tx *sqlx.Tx) error {
+		var result sql.Result
+		var err error
+		cond, args := versionCondition(id, userID, task.Version)
+		if nextDate == "" {
+			result, err = tx.ExecContext(ctx, tx.Rebind("DELETE FROM scheduler WHERE "+cond), args...)
+		} else {
+			result, err = tx.ExecContext(ctx, tx.Rebind(
+				"UPDATE scheduler SET date = ?, version = version + 1, updated_at = ? WHERE "+cond),
+				append([]any{nextDate, updatedAt()}, args...)...)
+		}
+		if err != nil {
+			return err
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rowsAffected == 0 {
+			if err := checkVersion(ctx, tx, userID, id, task.Version); err != nil {
+				return err
+			}
+			return ErrTaskNotFound
+		}
+		if nextDate == "" {
+			if err := deleteTaskTags(ctx, tx, id); err != nil {
+				return err
+			}
+			if err := s.dialect.unindexTask(tx, id); err != nil {
+				return err
+			}
+		}
+
+		var completionID int64
+		err = tx.QueryRowContext(ctx, tx.Rebind(`
+			INSERT INTO completions (user_id, task_id, title, comment, repeat, date, next_date,
+				priority, time, project, tags, completed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING id
+		`), userID, id, completion.Title, completion.Comment, completion.Repeat, completion.Date, completion.NextDate,
+			completion.Priority, completion.Time, completion.Project, strings.Join(completion.Tags, ","), completion.CompletedAt,
+		).Scan(&completionID)
+		completion.ID = strconv.FormatInt(completionID, 10)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &completion, nil
+}
+
+// ListCompletions возвращает историю выполнения задач пользователя, начиная с последних.
+func (s *SQLStore) ListCompletions(ctx context.Context, userID int64, filter CompletionFilter) ([]models.Completion, error) {
+	where := []string{"user_id = ?"}
+	args := []any{userID}
+	if !filter.From.IsZero() {
+		where = append(where, "completed_at >= ?")
+		args = append(args, filter.From.UTC().Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "completed_at < ?")
+		args = append(args, filter.To.UTC().Format(time.RFC3339))
+	}
+	if filter.TaskID != 0 {
+		where = append(where, "task_id = ?")
+		args = append(args, filter.TaskID)
+	}
+
+	query := "SELECT " + completionColumns + " FROM completions WHERE " +
+		strings.Join(where, " AND ") + " ORDER BY completed_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	completions := []models.Completion{}
+	for rows.Next() {
+		completion, err := scanCompletion(rows)
+		if err != nil {
+			return nil, err
+		}
+		completions = append(completions, *completion)
+	}
+	return completions, rows.Err()
+}
+
+// UndoCompletion отменяет последнее выполнение задачи: возвращает разовую задачу
+// с прежним ID или прежнюю дату повторяющейся и удаляет запись из истории.
+func (s *SQLStore) UndoCompletion(ctx context.Context, userID, taskID int64) (*models.Task, error) {
+	var task models.Task
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		row := tx.QueryRowContext(ctx, tx.Rebind(`
+			SELECT `+completionColumns+` FROM completions
+			WHERE user_id = ? AND task_id = ?
+			ORDER BY id DESC LIMIT 1
+		`), userID, taskID)
+		completion, err := scanCompletion(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return err
+		}
+
+		task = models.Task{
+			ID:       completion.TaskID,
+			Date:     completion.Date,
+			Title:    completion.Title,
+			Comment:  completion.Comment,
+			Repeat:   completion.Repeat,
+			Priority: completion.Priority,
+			Time:     completion.Time,
+			Project:  completion.Project,
+			Tags:     completion.Tags,
+		}
+		if completion.NextDate == "" {
+			project, err := projectID(ctx, tx, userID, task.Project)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, tx.Rebind(`
+				INSERT INTO scheduler (id, user_id, date, title, comment, repeat, priority, time, project_id, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`), taskID, userID, task.Date, task.Title, task.Comment, task.Repeat, task.Priority, task.Time, project, updatedAt())
+			if err != nil {
+				return err
+			}
+			if err := setTaskTags(ctx, tx, userID, taskID, task.Tags); err != nil {
+				return err
+			}
+			if err := s.dialect.indexTask(tx, taskID, task.Title, task.Comment); err != nil {
+				return err
+			}
+		} else {
+			// Остальные поля могли измениться после выполнения, возвращаем только дату
+			result, err := tx.ExecContext(ctx, tx.Rebind(
+				"UPDATE scheduler SET date = ?, version = version + 1, updated_at = ? WHERE id = ? AND user_id = ? AND deleted_at = ''"),
+				completion.Date, updatedAt(), taskID, userID,
+			)
+			if err != nil {
+				return err
+			}
+			if rowsAffected, err := result.RowsAffected(); err != nil {
+				return err
+			} else if rowsAffected == 0 {
+				return ErrTaskNotFound
+			}
+
+			current, err := getTask(ctx, tx, userID, taskID)
+			if err != nil {
+				return err
+			}
+			task = *current
+		}
+
+		id, _ := strconv.ParseInt(completion.ID, 10, 64)
+		_, err = tx.ExecContext(ctx, tx.Rebind("DELETE FROM completions WHERE id = ?"), id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// completionColumns столбцы записи истории в порядке, который ожидает scanCompletion
+const completionColumns = "id, task_id, title, comment, repeat, date, next_date, priority, time, project, tags, completed_at"
+
+// scanCompletion читает запись истории из строки результата запроса.
+func scanCompletion(row scanner) (*models.Completion, error) {
+	var completion models.Completion
+	var id, taskID int64
+	var tags string
+	err := row.Scan(&id, &taskID, &completion.Title, &completion.Comment, &completion.Repeat,
+		&completion.Date, &completion.NextDate, &completion.Priority, &completion.Time,
+		&completion.Project, &tags, &completion.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if tags != "" {
+		completion.Tags = strings.Split(tags, ",")
+	}
+	completion.ID = strconv.FormatInt(id, 10)
+	completion.TaskID = strconv.FormatInt(taskID, 10)
+	return &completion, nil
+}
@@ -1,145 +1,95 @@
 package db
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
-	"go_final_project/models"
-	"log"
-	"os"
-	"path/filepath"
-	"strconv"
+	"strings"
 
-	_ "modernc.org/sqlite" // Подключаем SQLite без CGO
+	"github.com/jmoiron/sqlx"
 )
 
-// GetDatabasePath возвращает путь к файлу базы данных.
-// Если переменная TODO_DBFILE задана, используется её значение. (Задача со звёздочкой)
-func GetDatabasePath() string {
-	dbPath := os.Getenv("TODO_DBFILE")
-	if dbPath == "" {
-		workingDir, err := os.Getwd()
-		if err != nil {
-			log.Fatalf("Failed to get current working directory: %v", err)
-		}
-		dbPath = filepath.Join(workingDir, "scheduler.db")
-	}
-	return dbPath
+// SQLStore реализация Store поверх database/sql. Различия между SQLite
+// и PostgreSQL (полнотекстовый поиск, коды ошибок, миграции) вынесены в dialect,
+// запросы пишутся с плейсхолдерами ? и приводятся к нужному виду через Rebind.
+type SQLStore struct {
+	db      *sqlx.DB
+	dialect dialect
 }
 
-// SetupDatabase проверяет наличие файла базы данных и создаёт таблицу, если её нет.
-func SetupDatabase(dbFile string) error {
-	_, err := os.Stat(dbFile)
-	var install bool
-	if err != nil && os.IsNotExist(err) {
-		install = true
-		log.Println("Database file not found, creating an empty database file.")
-
-		file, err := os.Create(dbFile)
-		if err != nil {
-			return fmt.Errorf("failed to create database file: %v", err)
-		}
-		file.Close()
-	}
-
-	db, err := sql.Open("sqlite", dbFile)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	if install {
-		err = createTable(db)
-		if err != nil {
-			return err
+// Open подключается к PostgreSQL, если databaseURL задан
+// (postgres://... или postgresql://...), иначе к файлу SQLite dbFile.
+// Миграции не применяются, для этого нужно вызвать Migrate.
+func Open(databaseURL, dbFile string) (*SQLStore, error) {
+	if databaseURL != "" {
+		if !strings.HasPrefix(databaseURL, "postgres://") && !strings.HasPrefix(databaseURL, "postgresql://") {
+			return nil, fmt.Errorf("unsupported database URL scheme: %s", databaseURL)
 		}
+		return openPostgres(databaseURL)
 	}
-	return nil
+	return openSQLite(dbFile)
 }
 
-// createTable создаёт таблицу и индекс по полю date.
-func createTable(db *sql.DB) error {
-	log.Println("Creating table 'scheduler'...")
-	query := `
-	CREATE TABLE IF NOT EXISTS scheduler (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		date TEXT NOT NULL,
-		title TEXT NOT NULL,
-		comment TEXT,
-		repeat TEXT CHECK(length(repeat) <= 128)
-	);
-	CREATE INDEX IF NOT EXISTS idx_date ON scheduler(date);
-	`
-	_, err := db.Exec(query)
-	if err != nil {
-		log.Printf("Failed to create table: %v", err)
+// Migrate применяет недостающие миграции и подготавливает поисковый индекс.
+func (s *SQLStore) Migrate() error {
+	if err := s.MigrateUp(0); err != nil {
 		return err
 	}
-	log.Println("Table 'scheduler' created successfully.")
-	return nil
+	return s.dialect.rebuildSearchIndex(s.db)
 }
 
-// AddTask добавляет новую задачу в таблицу scheduler и возвращает её ID.
-func AddTask(db *sql.DB, date, title, comment, repeat string) (int64, error) {
-	query := `
-		INSERT INTO scheduler (date, title, comment, repeat)
-		VALUES (?, ?, ?, ?)
-	`
-	res, err := db.Exec(query, date, title, comment, repeat)
-	if err != nil {
-		log.Printf("Failed to insert task: %v", err)
-		return 0, err
-	}
-
-	id, err := res.LastInsertId()
-	if err != nil {
-		log.Printf("Failed to retrieve last insert ID: %v", err)
-		return 0, err
-	}
-
-	return id, nil
+// Ping проверяет доступность базы данных.
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
 }
 
-// GetTaskByID возвращает данные задачи по её ID.
-func GetTaskByID(db *sql.DB, id int) (*models.Task, error) {
-	var task models.Task
-	row := db.QueryRow(
-		"SELECT id, date, title, comment, repeat FROM scheduler WHERE id = ?",
-		id,
-	)
+// ErrBackupUnsupported возвращается, если СУБД не поддерживает резервное копирование средствами сервера.
+var ErrBackupUnsupported = errors.New("backup is not supported for this database, use its own tools")
 
-	var taskID int64
-	err := row.Scan(&taskID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("task not found")
-		}
-		return nil, err
-	}
+// Backup сохраняет согласованную копию базы данных в файл path.
+// Файл не должен существовать.
+func (s *SQLStore) Backup(ctx context.Context, path string) error {
+	return s.dialect.backup(ctx, s.db, path)
+}
 
-	task.ID = strconv.FormatInt(taskID, 10)
-	return &task, nil
+// Close закрывает подключение к базе данных.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
 }
 
-// UpdateTask обновляет данные задачи.
-func UpdateTask(db *sql.DB, task models.Task) (int64, error) {
-	query := `
-		UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ?
-		WHERE id = ?
-	`
-	result, err := db.Exec(query, task.Date, task.Title, task.Comment, task.Repeat, task.ID)
+// inTx выполняет fn в транзакции.
+func (s *SQLStore) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return err
 	}
+	defer tx.Rollback()
 
-	return result.RowsAffected()
-}
-
-// DeleteTask удаляет задачу по её ID.
-func DeleteTask(db *sql.DB, id int) (int64, error) {
-	result, err := db.Exec("DELETE FROM scheduler WHERE id = ?", id)
-	if err != nil {
-		return 0, err
+	if err := fn(tx); err != nil {
+		return err
 	}
+	return tx.Commit()
+}
 
-	return result.RowsAffected()
+// dialect особенности конкретной СУБД.
+type dialect interface {
+	// name имя каталога с миграциями
+	name() string
+	// hasTable проверяет наличие таблицы
+	hasTable(q sqlx.Queryer, table string) (bool, error)
+	// legacyVersion версия схемы базы, созданной до появления миграций
+	legacyVersion(q sqlx.Queryer) (int, error)
+	// searchCondition условие WHERE для полнотекстового поиска,
+	// ok == false, если в строке поиска нет слов
+	searchCondition(search string) (cond string, arg any, ok bool)
+	// indexTask обновляет задачу в поисковом индексе
+	indexTask(tx *sqlx.Tx, id int64, title, comment string) error
+	// unindexTask удаляет задачу из поискового индекса
+	unindexTask(tx *sqlx.Tx, id int64) error
+	// rebuildSearchIndex заново строит поисковый индекс
+	rebuildSearchIndex(db *sqlx.DB) error
+	// isUniqueViolation проверяет, что ошибка вызвана нарушением уникальности
+	isUniqueViolation(err error) bool
+	// backup сохраняет копию базы данных в файл
+	backup(ctx context.Context, db *sqlx.DB, path string) error
 }
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+
+	"go_final_project/models"
+)
+
+// ListTags возвращает метки пользователя, назначенные хотя бы одной задаче.
+func (s *SQLStore) ListTags(ctx context.Context, userID int64) ([]string, error) {
+	return s.listLabels(ctx, `
+		SELECT name FROM tags
+		WHERE user_id = ? AND id IN (SELECT tag_id FROM task_tags)
+		ORDER BY name
+	`, userID)
+}
+
+// ListProjects возвращает проекты пользователя, в которых есть хотя бы одна задача.
+func (s *SQLStore) ListProjects(ctx context.Context, userID int64) ([]string, error) {
+	return s.listLabels(ctx, `
+		SELECT name FROM projects
+		WHERE user_id = ? AND id IN (SELECT project_id FROM scheduler)
+		ORDER BY name
+	`, userID)
+}
+
+func (s *SQLStore) listLabels(ctx context.Context, query string, userID int64) ([]string, error) {
+	names := []string{}
+	if err := s.db.SelectContext(ctx, &names, s.db.Rebind(query), userID); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// labelID возвращает ID метки или проекта пользователя по имени, создавая запись при необходимости.
+// table - tags или projects.
+func labelID(ctx context.Context, tx *sqlx.Tx, table string, userID int64, name string) (int64, error) {
+	_, err := tx.ExecContext(ctx, tx.Rebind(
+		"INSERT INTO "+table+" (user_id, name) VALUES (?, ?) ON CONFLICT DO NOTHING"),
+		userID, name,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, tx.Rebind(
+		"SELECT id FROM "+table+" WHERE user_id = ? AND name = ?"),
+		userID, name,
+	).Scan(&id)
+	return id, err
+}
+
+// projectID возвращает ID проекта задачи, 0 - задача без проекта.
+func projectID(ctx context.Context, tx *sqlx.Tx, userID int64, project string) (int64, error) {
+	if project == "" {
+		return 0, nil
+	}
+	return labelID(ctx, tx, "projects", userID, project)
+}
+
+// setTaskTags заменяет метки задачи.
+func setTaskTags(ctx context.Context, tx *sqlx.Tx, userID, taskID int64, tags []string) error {
+	if err := deleteTaskTags(ctx, tx, taskID); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		tagID, err := labelID(ctx, tx, "tags", userID, tag)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, tx.Rebind(
+			"INSERT INTO task_tags (task_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING"),
+			taskID, tagID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTaskTags удаляет связи задачи с метками.
+func deleteTaskTags(ctx context.Context, tx *sqlx.Tx, taskID int64) error {
+	_, err := tx.ExecContext(ctx, tx.Rebind("DELETE FROM task_tags WHERE task_id = ?"), taskID)
+	return err
+}
+
+// loadTags заполняет метки задач одним запросом.
+func loadTags(ctx context.Context, q sqlx.ExtContext, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(tasks))
+	index := make(map[int64]int, len(tasks))
+	for i, task := range tasks {
+		id, err := strconv.ParseInt(task.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		index[id] = i
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT task_tags.task_id, tags.name FROM task_tags
+		JOIN tags ON tags.id = task_tags.tag_id
+		WHERE task_tags.task_id IN (?)
+		ORDER BY tags.name
+	`, ids)
+	if err != nil {
+		return err
+	}
+	rows, err := q.QueryContext(ctx, q.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int64
+		var name string
+		if err := rows.Scan(&taskID, &name); err != nil {
+			return err
+		}
+		if i, ok := index[taskID]; ok {
+			tasks[i].Tags = append(tasks[i].Tags, name)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,270 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrSchemaTooNew возвращается, если база данных обновлена более новой версией программы.
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+// Migration описывает одну версию схемы базы данных.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState состояние миграции в базе данных.
+type MigrationState struct {
+	Migration
+	AppliedAt string // пустая строка, если миграция не применена
+}
+
+// Migrations возвращает встроенные миграции хранилища, упорядоченные по версии.
+// Файлы лежат в migrations/<СУБД> и называются NNNN_name.up.sql и NNNN_name.down.sql.
+func (s *SQLStore) Migrations() ([]Migration, error) {
+	dir := path.Join("migrations", s.dialect.name())
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		number, title, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+
+		body, err := migrationFiles.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration version %d is missing", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// MigrateUp применяет все миграции до версии target (0 - до последней).
+func (s *SQLStore) MigrateUp(target int) error {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return err
+	}
+	if target == 0 || target > len(migrations) {
+		target = len(migrations)
+	}
+
+	current, err := s.prepareMigrations(len(migrations))
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations[min(current, target):target] {
+		slog.Info("applying migration", "version", m.Version, "name", m.Name)
+		err := s.inTx(context.Background(), func(tx *sqlx.Tx) error {
+			if _, err := tx.Exec(m.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(tx.Rebind(
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+				m.Version, m.Name, time.Now().UTC().Format(time.RFC3339),
+			)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown откатывает последние steps миграций.
+func (s *SQLStore) MigrateDown(steps int) error {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return err
+	}
+
+	current, err := s.prepareMigrations(len(migrations))
+	if err != nil {
+		return err
+	}
+
+	for ; steps > 0 && current > 0; steps, current = steps-1, current-1 {
+		m := migrations[current-1]
+		if m.Down == "" {
+			return fmt.Errorf("migration %04d_%s cannot be rolled back", m.Version, m.Name)
+		}
+
+		slog.Info("rolling back migration", "version", m.Version, "name", m.Name)
+		err := s.inTx(context.Background(), func(tx *sqlx.Tx) error {
+			if _, err := tx.Exec(m.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(tx.Rebind("DELETE FROM schema_migrations WHERE version = ?"), m.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rollback of %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// MigrationStatus возвращает список миграций с отметкой о применении.
+func (s *SQLStore) MigrationStatus() ([]MigrationState, error) {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.prepareMigrations(-1); err != nil {
+		return nil, err
+	}
+
+	applied := map[int]string{}
+	rows, err := s.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		states = append(states, MigrationState{Migration: m, AppliedAt: applied[m.Version]})
+		delete(applied, m.Version)
+	}
+	for version, appliedAt := range applied {
+		states = append(states, MigrationState{
+			Migration: Migration{Version: version, Name: "unknown"},
+			AppliedAt: appliedAt,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Version < states[j].Version
+	})
+	return states, nil
+}
+
+// prepareMigrations создаёт таблицу schema_migrations, отмечает миграции
+// в базе, созданной до их появления, и возвращает текущую версию схемы.
+// Если known >= 0 и схема новее, возвращается ErrSchemaTooNew.
+func (s *SQLStore) prepareMigrations(known int) (int, error) {
+	exists, err := s.dialect.hasTable(s.db, "schema_migrations")
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		if err := s.createMigrationsTable(); err != nil {
+			return 0, err
+		}
+	}
+
+	var current int
+	err = s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return 0, err
+	}
+	if known >= 0 && current > known {
+		return 0, fmt.Errorf("%w: version %d, latest known %d", ErrSchemaTooNew, current, known)
+	}
+	return current, nil
+}
+
+// createMigrationsTable создаёт таблицу schema_migrations. Если база была
+// создана до появления миграций, уже существующие части схемы
+// отмечаются как применённые миграции.
+func (s *SQLStore) createMigrationsTable() error {
+	return s.inTx(context.Background(), func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(`
+		CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)`)
+		if err != nil {
+			return err
+		}
+
+		baseline, err := s.dialect.legacyVersion(tx)
+		if err != nil || baseline == 0 {
+			return err
+		}
+		slog.Info("existing database detected, marking schema version as applied", "version", baseline)
+
+		migrations, err := s.Migrations()
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		for _, m := range migrations[:min(baseline, len(migrations))] {
+			_, err := tx.Exec(tx.Rebind(
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+				m.Version, m.Name, now,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
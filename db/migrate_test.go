@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/models"
+)
+
+// applied возвращает версии применённых миграций
+func applied(t *testing.T, store *SQLStore) []int {
+	t.Helper()
+	states, err := store.MigrationStatus()
+	require.NoError(t, err)
+	var versions []int
+	for _, state := range states {
+		if state.AppliedAt != "" {
+			versions = append(versions, state.Version)
+		}
+	}
+	return versions
+}
+
+func TestMigrationFiles(t *testing.T) {
+	for _, store := range []*SQLStore{{dialect: sqliteDialect{}}, {dialect: postgresDialect{}}} {
+		migrations, err := store.Migrations()
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+		for i, m := range migrations {
+			assert.Equal(t, i+1, m.Version)
+			assert.NotEmpty(t, m.Name)
+			assert.NotEmpty(t, m.Down, "%s %04d_%s", store.dialect.name(), m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	store := openSQLiteStore(t)
+	migrations, err := store.Migrations()
+	require.NoError(t, err)
+
+	require.NoError(t, store.MigrateUp(3))
+	assert.Equal(t, []int{1, 2, 3}, applied(t, store))
+	ok, err := sqliteHasTable(store.db, "users")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = sqliteHasTable(store.db, "completions")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Повторный запуск ничего не меняет
+	require.NoError(t, store.MigrateUp(3))
+	assert.Equal(t, []int{1, 2, 3}, applied(t, store))
+
+	require.NoError(t, store.MigrateDown(1))
+	assert.Equal(t, []int{1, 2}, applied(t, store))
+	ok, err = sqliteHasTable(store.db, "users")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, store.MigrateUp(0))
+	assert.Len(t, applied(t, store), len(migrations))
+
+	// Откат на больше шагов, чем применено, останавливается на пустой схеме
+	require.NoError(t, store.MigrateDown(len(migrations)+5))
+	assert.Empty(t, applied(t, store))
+	ok, err = sqliteHasTable(store.db, "scheduler")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSchemaTooNew(t *testing.T) {
+	store := openSQLiteStore(t)
+	require.NoError(t, store.Migrate())
+	_, err := store.db.Exec(
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (999, 'future', '2030-01-01T00:00:00Z')")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, store.MigrateUp(0), ErrSchemaTooNew)
+	assert.ErrorIs(t, store.MigrateDown(1), ErrSchemaTooNew)
+	assert.ErrorIs(t, store.Migrate(), ErrSchemaTooNew)
+
+	// Состояние можно посмотреть и у слишком новой схемы
+	states, err := store.MigrationStatus()
+	require.NoError(t, err)
+	last := states[len(states)-1]
+	assert.Equal(t, 999, last.Version)
+	assert.Equal(t, "unknown", last.Name)
+}
+
+func TestLegacyVersion(t *testing.T) {
+	store := openSQLiteStore(t)
+	migrations, err := store.Migrations()
+	require.NoError(t, err)
+
+	// Схема, созданная до появления миграций, распознаётся по таблицам и столбцам
+	for version := 0; version <= 3; version++ {
+		if version > 0 {
+			_, err := store.db.Exec(migrations[version-1].Up)
+			require.NoError(t, err)
+		}
+		got, err := sqliteDialect{}.legacyVersion(store.db)
+		require.NoError(t, err)
+		assert.Equal(t, version, got)
+	}
+	_, err = store.db.Exec("INSERT INTO scheduler (date, title, comment, repeat) VALUES ('20240115', 'Старая задача', '', '')")
+	require.NoError(t, err)
+
+	// Распознанные миграции отмечаются применёнными, остальные применяются, данные сохраняются
+	require.NoError(t, store.Migrate())
+	assert.Len(t, applied(t, store), len(migrations))
+	tasks, err := store.ListTasks(context.Background(), 0, TaskFilter{Search: "старая"})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Старая задача", tasks[0].Title)
+
+	// Задачи добавляются в обновлённую схему
+	_, err = store.AddTask(context.Background(), 0, models.Task{Date: "20240116", Title: "Новая задача"})
+	assert.NoError(t, err)
+}
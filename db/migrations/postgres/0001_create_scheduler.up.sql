@@ -0,0 +1,8 @@
+CREATE TABLE scheduler (
+	id BIGSERIAL PRIMARY KEY,
+	date TEXT NOT NULL,
+	title TEXT NOT NULL,
+	comment TEXT,
+	repeat TEXT CHECK(length(repeat) <= 128)
+);
+CREATE INDEX idx_date ON scheduler(date);
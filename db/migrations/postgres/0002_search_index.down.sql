@@ -0,0 +1,2 @@
+DROP INDEX idx_search;
+ALTER TABLE scheduler DROP COLUMN search;
@@ -0,0 +1,3 @@
+ALTER TABLE scheduler ADD COLUMN search tsvector
+	GENERATED ALWAYS AS (to_tsvector('simple', title || ' ' || COALESCE(comment, ''))) STORED;
+CREATE INDEX idx_search ON scheduler USING GIN (search);
@@ -0,0 +1,4 @@
+DROP INDEX idx_user_date;
+ALTER TABLE scheduler DROP COLUMN user_id;
+DROP TABLE settings;
+DROP TABLE users;
@@ -0,0 +1,12 @@
+CREATE TABLE users (
+	id BIGSERIAL PRIMARY KEY,
+	login TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+CREATE TABLE settings (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+ALTER TABLE scheduler ADD COLUMN user_id BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX idx_user_date ON scheduler(user_id, date);
@@ -0,0 +1,13 @@
+CREATE TABLE completions (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	task_id BIGINT NOT NULL,
+	title TEXT NOT NULL,
+	comment TEXT NOT NULL DEFAULT '',
+	repeat TEXT NOT NULL DEFAULT '',
+	date TEXT NOT NULL,
+	next_date TEXT NOT NULL DEFAULT '',
+	completed_at TEXT NOT NULL
+);
+CREATE INDEX idx_completions_user ON completions(user_id, completed_at);
+CREATE INDEX idx_completions_task ON completions(task_id);
@@ -0,0 +1,3 @@
+DELETE FROM scheduler WHERE deleted_at <> '';
+DROP INDEX idx_deleted_at;
+ALTER TABLE scheduler DROP COLUMN deleted_at;
@@ -0,0 +1,6 @@
+ALTER TABLE completions DROP COLUMN tags;
+ALTER TABLE completions DROP COLUMN project;
+ALTER TABLE scheduler DROP COLUMN project_id;
+DROP TABLE task_tags;
+DROP TABLE tags;
+DROP TABLE projects;
@@ -0,0 +1,4 @@
+ALTER TABLE completions DROP COLUMN time;
+ALTER TABLE completions DROP COLUMN priority;
+ALTER TABLE scheduler DROP COLUMN time;
+ALTER TABLE scheduler DROP COLUMN priority;
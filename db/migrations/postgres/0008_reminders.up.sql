@@ -0,0 +1,7 @@
+CREATE TABLE reminders (
+	task_id BIGINT NOT NULL,
+	date TEXT NOT NULL,
+	channel TEXT NOT NULL,
+	sent_at TEXT NOT NULL,
+	PRIMARY KEY (task_id, date, channel)
+);
@@ -0,0 +1,22 @@
+CREATE TABLE webhooks (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL
+);
+CREATE INDEX idx_webhooks_user ON webhooks(user_id);
+CREATE TABLE webhook_deliveries (
+	id BIGSERIAL PRIMARY KEY,
+	webhook_id BIGINT NOT NULL,
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TEXT NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL
+);
+CREATE INDEX idx_deliveries_status ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX idx_deliveries_webhook ON webhook_deliveries(webhook_id);
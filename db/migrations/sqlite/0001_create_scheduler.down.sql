@@ -0,0 +1,2 @@
+DROP INDEX idx_date;
+DROP TABLE scheduler;
@@ -0,0 +1,7 @@
+CREATE VIRTUAL TABLE scheduler_fts USING fts5(
+	title,
+	comment,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+INSERT INTO scheduler_fts (rowid, title, comment)
+	SELECT id, title, COALESCE(comment, '') FROM scheduler;
@@ -0,0 +1,2 @@
+ALTER TABLE scheduler ADD COLUMN deleted_at TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_deleted_at ON scheduler(deleted_at);
@@ -0,0 +1,21 @@
+CREATE TABLE projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	UNIQUE (user_id, name)
+);
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	UNIQUE (user_id, name)
+);
+CREATE TABLE task_tags (
+	task_id INTEGER NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY (task_id, tag_id)
+);
+CREATE INDEX idx_task_tags_tag ON task_tags(tag_id);
+ALTER TABLE scheduler ADD COLUMN project_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE completions ADD COLUMN project TEXT NOT NULL DEFAULT '';
+ALTER TABLE completions ADD COLUMN tags TEXT NOT NULL DEFAULT '';
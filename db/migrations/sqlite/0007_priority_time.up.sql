@@ -0,0 +1,4 @@
+ALTER TABLE scheduler ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE scheduler ADD COLUMN time TEXT NOT NULL DEFAULT '';
+ALTER TABLE completions ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE completions ADD COLUMN time TEXT NOT NULL DEFAULT '';
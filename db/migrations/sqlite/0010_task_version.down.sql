@@ -0,0 +1,2 @@
+ALTER TABLE scheduler DROP COLUMN updated_at;
+ALTER TABLE scheduler DROP COLUMN version;
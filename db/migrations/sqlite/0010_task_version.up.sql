@@ -0,0 +1,2 @@
+ALTER TABLE scheduler ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE scheduler ADD COLUMN updated_at TEXT NOT NULL DEFAULT '';
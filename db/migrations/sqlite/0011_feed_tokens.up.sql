@@ -0,0 +1,5 @@
+CREATE TABLE feed_tokens (
+	user_id INTEGER PRIMARY KEY,
+	token_hash TEXT NOT NULL UNIQUE,
+	created_at TEXT NOT NULL
+);
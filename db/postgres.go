@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib" // Драйвер PostgreSQL для database/sql
+	"github.com/jmoiron/sqlx"
+)
+
+// openPostgres подключается к PostgreSQL по строке подключения.
+func openPostgres(databaseURL string) (*SQLStore, error) {
+	conn, err := sqlx.Open("pgx", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLStore{db: conn, dialect: postgresDialect{}}, nil
+}
+
+// postgresDialect особенности PostgreSQL. Поисковый индекс - генерируемый
+// столбец tsvector с GIN-индексом, поэтому отдельно его обновлять не нужно.
+type postgresDialect struct{}
+
+func (postgresDialect) name() string {
+	return "postgres"
+}
+
+func (postgresDialect) hasTable(q sqlx.Queryer, table string) (bool, error) {
+	var exists bool
+	err := q.QueryRowx("SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists)
+	return exists, err
+}
+
+// legacyVersion всегда 0: в PostgreSQL схема создавалась только миграциями.
+func (postgresDialect) legacyVersion(sqlx.Queryer) (int, error) {
+	return 0, nil
+}
+
+// searchCondition ищет каждое слово как префикс, все слова должны присутствовать.
+func (postgresDialect) searchCondition(search string) (string, any, bool) {
+	var terms []string
+	for _, word := range searchTerms(search) {
+		terms = append(terms, "'"+strings.ReplaceAll(word, "'", "''")+"':*")
+	}
+	if len(terms) == 0 {
+		return "", nil, false
+	}
+	return "search @@ to_tsquery('simple', ?)", strings.Join(terms, " & "), true
+}
+
+func (postgresDialect) indexTask(*sqlx.Tx, int64, string, string) error {
+	return nil
+}
+
+func (postgresDialect) unindexTask(*sqlx.Tx, int64) error {
+	return nil
+}
+
+func (postgresDialect) rebuildSearchIndex(*sqlx.DB) error {
+	return nil
+}
+
+func (postgresDialect) isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (postgresDialect) backup(context.Context, *sqlx.DB, string) error {
+	return ErrBackupUnsupported
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go_final_project/models"
+)
+
+// PendingReminders возвращает задачи пользователя на дату date,
+// напоминание о которых ещё не отправлено по каналу channel.
+func (s *SQLStore) PendingReminders(ctx context.Context, userID int64, date, channel string) ([]models.Task, error) {
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(`
+		SELECT `+taskColumns+` FROM scheduler
+		WHERE user_id = ? AND date = ? AND deleted_at = '' AND NOT EXISTS (
+			SELECT 1 FROM reminders
+			WHERE reminders.task_id = scheduler.id AND reminders.date = scheduler.date AND reminders.channel = ?
+		)
+		ORDER BY `+taskOrder,
+	), userID, date, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	return tasks, rows.Err()
+}
+
+// MarkReminderSent запоминает, что напоминание о задаче на дату date отправлено по каналу channel.
+// Старые записи, которые уже не понадобятся, удаляются.
+func (s *SQLStore) MarkReminderSent(ctx context.Context, taskID int64, date, channel string) error {
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(
+		"INSERT INTO reminders (task_id, date, channel, sent_at) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING"),
+		taskID, date, channel, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM reminders WHERE date < ?"), date)
+	return err
+}
@@ -0,0 +1,16 @@
+package db
+
+import "strings"
+
+// searchTerms разбивает строку поиска на слова, отбрасывая
+// символы, которые имеют особое значение в языках запросов FTS.
+func searchTerms(search string) []string {
+	var terms []string
+	for _, word := range strings.Fields(search) {
+		word = strings.Trim(word, `"'*:&|!()`)
+		if word != "" {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite" // Подключаем SQLite без CGO
+)
+
+// openSQLite открывает файл базы данных SQLite, создавая его при необходимости.
+func openSQLite(dbFile string) (*SQLStore, error) {
+	// busy_timeout нужен, чтобы параллельные записи ждали друг друга, а не падали с SQLITE_BUSY
+	conn, err := sqlx.Open("sqlite", dbFile+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	return &SQLStore{db: conn, dialect: sqliteDialect{}}, nil
+}
+
+// sqliteDialect особенности SQLite.
+//
+// Полнотекстовый индекс хранится в отдельной таблице FTS5 и обновляется
+// методами хранилища, а не триггерами: к базе могут подключаться
+// клиенты без модуля fts5 (например, тесты через mattn/go-sqlite3),
+// и триггеры сломали бы им любую запись в scheduler.
+type sqliteDialect struct{}
+
+func (sqliteDialect) name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) hasTable(q sqlx.Queryer, table string) (bool, error) {
+	return sqliteHasTable(q, table)
+}
+
+// legacyVersion определяет версию схемы базы, созданной без миграций,
+// по наличию таблиц и столбцов.
+func (sqliteDialect) legacyVersion(q sqlx.Queryer) (int, error) {
+	checks := []func() (bool, error){
+		func() (bool, error) { return sqliteHasTable(q, "scheduler") },
+		func() (bool, error) { return sqliteHasTable(q, "scheduler_fts") },
+		func() (bool, error) { return sqliteHasColumn(q, "scheduler", "user_id") },
+	}
+	version := 0
+	for _, check := range checks {
+		ok, err := check()
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		version++
+	}
+	return version, nil
+}
+
+// searchCondition ищет каждое слово как префикс, все слова должны присутствовать.
+// Слова берутся в кавычки, чтобы спецсимволы не ломали синтаксис FTS5.
+func (sqliteDialect) searchCondition(search string) (string, any, bool) {
+	var terms []string
+	for _, word := range searchTerms(search) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	if len(terms) == 0 {
+		return "", nil, false
+	}
+	return "id IN (SELECT rowid FROM scheduler_fts WHERE scheduler_fts MATCH ?)", strings.Join(terms, " "), true
+}
+
+func (sqliteDialect) indexTask(tx *sqlx.Tx, id int64, title, comment string) error {
+	if _, err := tx.Exec("DELETE FROM scheduler_fts WHERE rowid = ?", id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		"INSERT INTO scheduler_fts (rowid, title, comment) VALUES (?, ?, ?)",
+		id, title, comment,
+	)
+	return err
+}
+
+func (sqliteDialect) unindexTask(tx *sqlx.Tx, id int64) error {
+	_, err := tx.Exec("DELETE FROM scheduler_fts WHERE rowid = ?", id)
+	return err
+}
+
+// rebuildSearchIndex заново заполняет индекс из scheduler,
+// чтобы учесть изменения, сделанные в обход сервера.
+func (sqliteDialect) rebuildSearchIndex(db *sqlx.DB) error {
+	query := `
+	DELETE FROM scheduler_fts;
+	INSERT INTO scheduler_fts (rowid, title, comment)
+		SELECT id, title, COALESCE(comment, '') FROM scheduler;
+	`
+	if _, err := db.Exec(query); err != nil {
+		slog.Error("failed to rebuild search index", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (sqliteDialect) isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// backup копирует базу командой VACUUM INTO, которая не блокирует запись надолго
+// и даёт согласованный снимок даже во время работы сервера.
+func (sqliteDialect) backup(ctx context.Context, db *sqlx.DB, path string) error {
+	_, err := db.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
+
+// sqliteHasTable проверяет наличие таблицы.
+func sqliteHasTable(q sqlx.Queryer, table string) (bool, error) {
+	var count int
+	err := q.QueryRowx("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+	return count > 0, err
+}
+
+// sqliteHasColumn проверяет наличие столбца в таблице.
+func sqliteHasColumn(q sqlx.Queryer, table, column string) (bool, error) {
+	var count int
+	err := q.QueryRowx("SELECT count(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	return count > 0, err
+}
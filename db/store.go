@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_final_project/models"
+)
+
+// ErrTaskNotFound возвращается, если задача не найдена.
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrVersionConflict возвращается, если задачу изменили после того, как клиент
+// прочитал указанную им версию.
+var ErrVersionConflict = errors.New("task version conflict")
+
+// TaskFilter условия выборки списка задач.
+type TaskFilter struct {
+	Date   string // дата в формате YYYYMMDD, если задана, поиск не выполняется
+	Search string // слова для полнотекстового поиска по заголовку и комментарию
+	Limit  int    // 0 - без ограничения
+
+	Until   string   // только задачи с датой не позже указанной (YYYYMMDD)
+	Project string   // только задачи проекта
+	Tags    []string // только задачи со всеми указанными метками
+
+	After *TaskCursor // только задачи, которые в списке идут после указанной
+}
+
+// TaskCursor позиция задачи в списке: значения всех полей сортировки.
+type TaskCursor struct {
+	Date     string
+	Time     string
+	Priority int
+	ID       int64
+}
+
+// CompletionFilter условия выборки истории выполнения задач.
+type CompletionFilter struct {
+	From   time.Time // начало периода, нулевое значение - без ограничения
+	To     time.Time // конец периода (не включается), нулевое значение - без ограничения
+	TaskID int64     // 0 - все задачи
+	Limit  int       // 0 - без ограничения
+}
+
+// TaskStore хранилище задач. Все методы работают только с задачами указанного пользователя.
+type TaskStore interface {
+	AddTask(ctx context.Context, userID int64, task models.Task) (int64, error)
+	AddTasks(ctx context.Context, userID int64, tasks []models.Task) ([]int64, error)
+	GetTaskByID(ctx context.Context, userID, id int64) (*models.Task, error)
+	// UpdateTask обновляет задачу. Если task.Version не 0, задача обновляется,
+	// только пока её версия совпадает, иначе возвращается ErrVersionConflict.
+	UpdateTask(ctx context.Context, userID int64, task models.Task) (int64, error)
+	DeleteTask(ctx context.Context, userID, id int64) (int64, error)
+	ListTasks(ctx context.Context, userID int64, filter TaskFilter) ([]models.Task, error)
+	ListTags(ctx context.Context, userID int64) ([]string, error)
+	ListProjects(ctx context.Context, userID int64) ([]string, error)
+}
+
+// TrashStore корзина удалённых задач.
+type TrashStore interface {
+	ListTrash(ctx context.Context, userID int64) ([]models.Task, error)
+	RestoreTask(ctx context.Context, userID, id int64) (int64, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+}
+
+// CompletionStore история выполнения задач.
+type CompletionStore interface {
+	// CompleteTask проверяет версию задачи так же, как UpdateTask.
+	CompleteTask(ctx context.Context, userID int64, task models.Task, nextDate string) (*models.Completion, error)
+	ListCompletions(ctx context.Context, userID int64, filter CompletionFilter) ([]models.Completion, error)
+	UndoCompletion(ctx context.Context, userID, taskID int64) (*models.Task, error)
+}
+
+// ReminderStore состояние доставки напоминаний. Напоминание о задаче
+// отправляется по каждому каналу один раз для каждой даты задачи.
+type ReminderStore interface {
+	PendingReminders(ctx context.Context, userID int64, date, channel string) ([]models.Task, error)
+	MarkReminderSent(ctx context.Context, taskID int64, date, channel string) error
+}
+
+// WebhookStore подписки на события и очередь их доставки.
+type WebhookStore interface {
+	AddWebhook(ctx context.Context, userID int64, webhook models.Webhook) (int64, error)
+	ListWebhooks(ctx context.Context, userID int64) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, id int64) (int64, error)
+	EnqueueDeliveries(ctx context.Context, userID int64, event string, payload []byte) (int64, error)
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	ListDeadDeliveries(ctx context.Context, userID int64) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, userID, id int64) (int64, error)
+	PurgeDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Состояния задач в статистике CountTasks.
+const (
+	TaskStateActive  = "active"  // задача на сегодня или позже
+	TaskStateOverdue = "overdue" // дата задачи уже прошла
+	TaskStateTrash   = "trash"   // задача в корзине
+)
+
+// StatsStore сводные данные по задачам всех пользователей.
+type StatsStore interface {
+	// CountTasks возвращает число задач в каждом состоянии на дату today (YYYYMMDD).
+	CountTasks(ctx context.Context, today string) (map[string]int64, error)
+}
+
+// UserStore хранилище учётных записей.
+type UserStore interface {
+	AddUser(ctx context.Context, login, passwordHash string) (int64, error)
+	GetUserByLogin(ctx context.Context, login string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	TokenSecret(ctx context.Context) (string, error)
+	ResetFeedToken(ctx context.Context, userID int64) (string, error)
+	RevokeFeedToken(ctx context.Context, userID int64) error
+	FeedTokenUser(ctx context.Context, token string) (int64, error)
+}
+
+// Store всё хранилище, от которого зависят обработчики.
+type Store interface {
+	TaskStore
+	TrashStore
+	CompletionStore
+	ReminderStore
+	WebhookStore
+	StatsStore
+	UserStore
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+var _ Store = (*SQLStore)(nil)
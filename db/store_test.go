@@ -0,0 +1,285 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/models"
+)
+
+// TestDatabaseURLEnv переменная окружения со строкой подключения к PostgreSQL для тестов.
+// Если она не задана, тесты PostgreSQL пропускаются. Каждый тест работает в своей
+// временной схеме, поэтому подойдёт и база с данными.
+const TestDatabaseURLEnv = "TODO_TEST_DATABASE_URL"
+
+// openSQLiteStore создаёт пустую базу SQLite во временном каталоге
+func openSQLiteStore(t *testing.T) *SQLStore {
+	t.Helper()
+	store, err := Open("", filepath.Join(t.TempDir(), "scheduler.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// openPostgresStore создаёт временную схему в базе TODO_TEST_DATABASE_URL
+// и подключается к ней. Схема удаляется после теста.
+func openPostgresStore(t *testing.T) *SQLStore {
+	t.Helper()
+	databaseURL := os.Getenv(TestDatabaseURLEnv)
+	if databaseURL == "" {
+		t.Skip(TestDatabaseURLEnv + " is not set")
+	}
+
+	admin, err := sqlx.Open("pgx", databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+	schema := fmt.Sprintf("todo_test_%d", time.Now().UnixNano())
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	u, err := url.Parse(databaseURL)
+	require.NoError(t, err)
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	store, err := Open(u.String(), "")
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// forEachStore выполняет test на пустой базе SQLite и, если задан
+// TODO_TEST_DATABASE_URL, на пустой схеме PostgreSQL
+func forEachStore(t *testing.T, test func(t *testing.T, store *SQLStore)) {
+	t.Run("sqlite", func(t *testing.T) { test(t, openSQLiteStore(t)) })
+	t.Run("postgres", func(t *testing.T) { test(t, openPostgresStore(t)) })
+}
+
+func TestStoreMigrations(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *SQLStore) {
+		require.NoError(t, store.Migrate())
+		migrations, err := store.Migrations()
+		require.NoError(t, err)
+		states, err := store.MigrationStatus()
+		require.NoError(t, err)
+		require.Len(t, states, len(migrations))
+		for _, state := range states {
+			assert.NotEmpty(t, state.AppliedAt, "%04d_%s", state.Version, state.Name)
+		}
+
+		// Все миграции откатываются и применяются заново
+		require.NoError(t, store.MigrateDown(len(migrations)))
+		states, err = store.MigrationStatus()
+		require.NoError(t, err)
+		for _, state := range states {
+			assert.Empty(t, state.AppliedAt, "%04d_%s", state.Version, state.Name)
+		}
+		require.NoError(t, store.Migrate())
+		_, err = store.AddTask(context.Background(), 1, models.Task{Date: "20240115", Title: "После отката"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestStoreTasks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *SQLStore) {
+		require.NoError(t, store.Migrate())
+		ctx := context.Background()
+
+		id, err := store.AddTask(ctx, 1, models.Task{
+			Date: "20240115", Title: "Отчёт", Comment: "за квартал", Repeat: "d 7",
+			Priority: 2, Time: "10:30", Project: "Работа", Tags: []string{"срочно", "финансы"},
+		})
+		require.NoError(t, err)
+
+		task, err := store.GetTaskByID(ctx, 1, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Отчёт", task.Title)
+		assert.Equal(t, "Работа", task.Project)
+		assert.ElementsMatch(t, []string{"срочно", "финансы"}, task.Tags)
+		assert.Equal(t, int64(1), task.Version)
+
+		// Другой пользователь задачу не видит и не меняет
+		_, err = store.GetTaskByID(ctx, 2, id)
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		other := *task
+		other.Version = 0
+		rows, err := store.UpdateTask(ctx, 2, other)
+		assert.NoError(t, err)
+		assert.Zero(t, rows)
+
+		// Запись по устаревшей версии отклоняется
+		task.Title = "Отчёт за квартал"
+		task.Tags = []string{"финансы"}
+		rows, err = store.UpdateTask(ctx, 1, *task)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rows)
+		_, err = store.UpdateTask(ctx, 1, *task)
+		assert.ErrorIs(t, err, ErrVersionConflict)
+
+		task, err = store.GetTaskByID(ctx, 1, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Отчёт за квартал", task.Title)
+		assert.Equal(t, []string{"финансы"}, task.Tags)
+		assert.Equal(t, int64(2), task.Version)
+
+		completion, err := store.CompleteTask(ctx, 1, *task, "20240122")
+		require.NoError(t, err)
+		assert.Equal(t, "20240122", completion.NextDate)
+		task, err = store.GetTaskByID(ctx, 1, id)
+		require.NoError(t, err)
+		assert.Equal(t, "20240122", task.Date)
+
+		// Удалённая задача попадает в корзину и восстанавливается
+		rows, err = store.DeleteTask(ctx, 1, id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rows)
+		_, err = store.GetTaskByID(ctx, 1, id)
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		trash, err := store.ListTrash(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		rows, err = store.RestoreTask(ctx, 1, id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rows)
+		_, err = store.GetTaskByID(ctx, 1, id)
+		assert.NoError(t, err)
+	})
+}
+
+func TestStoreSearch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *SQLStore) {
+		require.NoError(t, store.Migrate())
+		ctx := context.Background()
+		for _, task := range []models.Task{
+			{Date: "20240115", Title: "Позвонить в банк", Comment: "уточнить кредит"},
+			{Date: "20240116", Title: "Банкет", Comment: "заказать зал"},
+			{Date: "20240117", Title: "Купить молоко"},
+		} {
+			_, err := store.AddTask(ctx, 1, task)
+			require.NoError(t, err)
+		}
+		_, err := store.AddTask(ctx, 2, models.Task{Date: "20240115", Title: "Банк другого пользователя"})
+		require.NoError(t, err)
+
+		titles := func(search string) []string {
+			tasks, err := store.ListTasks(ctx, 1, TaskFilter{Search: search})
+			require.NoError(t, err)
+			var titles []string
+			for _, task := range tasks {
+				titles = append(titles, task.Title)
+			}
+			return titles
+		}
+		// Слова ищутся как префиксы, в заголовке и комментарии, все слова должны найтись
+		assert.Equal(t, []string{"Позвонить в банк", "Банкет"}, titles("банк"))
+		assert.Equal(t, []string{"Позвонить в банк"}, titles("банк кредит"))
+		assert.Equal(t, []string{"Банкет"}, titles("зал"))
+		assert.Empty(t, titles("хлеб"))
+		assert.Len(t, titles(`"'*`), 3)
+
+		// Изменённая задача ищется по новому заголовку
+		tasks, err := store.ListTasks(ctx, 1, TaskFilter{Search: "молоко"})
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		tasks[0].Title = "Купить кефир"
+		_, err = store.UpdateTask(ctx, 1, tasks[0])
+		require.NoError(t, err)
+		assert.Empty(t, titles("молоко"))
+		assert.Equal(t, []string{"Купить кефир"}, titles("кефир"))
+	})
+}
+
+func TestStoreCursor(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *SQLStore) {
+		require.NoError(t, store.Migrate())
+		ctx := context.Background()
+		for i, task := range []models.Task{
+			{Date: "20240115", Time: "09:00"},
+			{Date: "20240115", Time: "09:00", Priority: 3},
+			{Date: "20240115"},
+			{Date: "20240115", Priority: 1},
+			{Date: "20240115", Time: "18:00"},
+			{Date: "20240116"},
+			{Date: "20240114", Time: "23:59"},
+			{Date: "20240116", Time: "08:00"},
+		} {
+			task.Title = "Задача " + strconv.Itoa(i)
+			_, err := store.AddTask(ctx, 1, task)
+			require.NoError(t, err)
+		}
+
+		all, err := store.ListTasks(ctx, 1, TaskFilter{})
+		require.NoError(t, err)
+		require.Len(t, all, 8)
+		assert.Equal(t, "Задача 6", all[0].Title)
+		assert.Equal(t, "Задача 1", all[1].Title)
+		assert.Equal(t, "Задача 3", all[4].Title)
+
+		// Страницы по курсору складываются в полный список без пропусков и повторов
+		var paged []models.Task
+		var after *TaskCursor
+		for {
+			page, err := store.ListTasks(ctx, 1, TaskFilter{Limit: 3, After: after})
+			require.NoError(t, err)
+			paged = append(paged, page...)
+			if len(page) < 3 {
+				break
+			}
+			last := page[len(page)-1]
+			id, err := strconv.ParseInt(last.ID, 10, 64)
+			require.NoError(t, err)
+			after = &TaskCursor{Date: last.Date, Time: last.Time, Priority: last.Priority, ID: id}
+		}
+		assert.Equal(t, all, paged)
+	})
+}
+
+func TestStorePurgeDeliveries(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store *SQLStore) {
+		require.NoError(t, store.Migrate())
+		ctx := context.Background()
+		_, err := store.AddWebhook(ctx, 1, models.Webhook{
+			URL: "https://example.com/hook", Secret: "secret", CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		})
+		require.NoError(t, err)
+		for _, event := range []string{"task.created", "task.updated", "task.done"} {
+			_, err := store.EnqueueDeliveries(ctx, 1, event, []byte(`{}`))
+			require.NoError(t, err)
+		}
+		deliveries, err := store.DueDeliveries(ctx, time.Now().Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 3)
+		deliveries[0].Status = models.DeliveryDelivered
+		require.NoError(t, store.UpdateDelivery(ctx, deliveries[0]))
+		deliveries[1].Status = models.DeliveryDead
+		require.NoError(t, store.UpdateDelivery(ctx, deliveries[1]))
+
+		// Свежие доставки не удаляются
+		purged, err := store.PurgeDeliveries(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		// Удаляются только доставленные: недоставленные можно вернуть в очередь
+		purged, err = store.PurgeDeliveries(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		dead, err := store.ListDeadDeliveries(ctx, 1)
+		require.NoError(t, err)
+		assert.Len(t, dead, 1)
+		pending, err := store.DueDeliveries(ctx, time.Now().Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.Len(t, pending, 1)
+	})
+}
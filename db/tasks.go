@@ -0,0 +1,404 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"go_final_project/models"
+)
+
+// AddTask добавляет новую задачу пользователя в таблицу scheduler и возвращает её ID.
+func (s *SQLStore) AddTask(ctx context.Context, userID int64, task models.Task) (int64, error) {
+	var id int64
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		id, err = s.insertTask(ctx, tx, userID, task)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// AddTasks добавляет задачи пользователя в одной транзакции и возвращает их ID
+// в том же порядке. При ошибке не добавляется ни одна задача.
+func (s *SQLStore) AddTasks(ctx context.Context, userID int64, tasks []models.Task) ([]int64, error) {
+	ids := make([]int64, 0, len(tasks))
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		for _, task := range tasks {
+			id, err := s.insertTask(ctx, tx, userID, task)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// insertTask добавляет задачу с метками и её запись в поисковом индексе в рамках транзакции.
+func (s *SQLStore) insertTask(ctx context.Context, tx *sqlx.Tx, userID int64, task models.Task) (int64, error) {
+	project, err := projectID(ctx, tx, userID, task.Project)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	query := tx.Rebind(`
+		INSERT INTO scheduler (user_id, date, title, comment, repeat, priority, time, project_id, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`)
+	err = tx.QueryRowContext(ctx, query, userID, task.Date, task.Title, task.Comment, task.Repeat,
+		task.Priority, task.Time, project, updatedAt()).Scan(&id)
+	if err != nil {
+		slog.DebugContext(ctx, "failed to insert task", "error", err)
+		return 0, err
+	}
+
+	if err := setTaskTags(ctx, tx, userID, id, task.Tags); err != nil {
+		slog.DebugContext(ctx, "failed to tag task", "task_id", id, "error", err)
+		return 0, err
+	}
+
+	if err := s.dialect.indexTask(tx, id, task.Title, task.Comment); err != nil {
+		slog.DebugContext(ctx, "failed to index task", "task_id", id, "error", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetTaskByID возвращает данные задачи пользователя по её ID.
+func (s *SQLStore) GetTaskByID(ctx context.Context, userID, id int64) (*models.Task, error) {
+	return getTask(ctx, s.db, userID, id)
+}
+
+// getTask читает задачу пользователя вместе с метками.
+func getTask(ctx context.Context, q sqlx.ExtContext, userID, id int64) (*models.Task, error) {
+	row := q.QueryRowxContext(ctx, q.Rebind(
+		"SELECT "+taskColumns+" FROM scheduler WHERE id = ? AND user_id = ? AND deleted_at = ''"),
+		id, userID,
+	)
+
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{*task}
+	if err := loadTags(ctx, q, tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// UpdateTask обновляет данные задачи пользователя, включая проект и метки, и увеличивает
+// её версию. Если task.Version не 0, обновляется только задача этой версии.
+func (s *SQLStore) UpdateTask(ctx context.Context, userID int64, task models.Task) (int64, error) {
+	id, err := strconv.ParseInt(task.ID, 10, 64)
+	if err != nil {
+		return 0, ErrTaskNotFound
+	}
+
+	var rowsAffected int64
+	err = s.inTx(ctx, func(tx *sqlx.Tx) error {
+		project, err := projectID(ctx, tx, userID, task.Project)
+		if err != nil {
+			return err
+		}
+
+		cond, args := versionCondition(id, userID, task.Version)
+		query := tx.Rebind(`
+			UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ?, priority = ?, time = ?, project_id = ?,
+				version = version + 1, updated_at = ?
+			WHERE ` + cond)
+		result, err := tx.ExecContext(ctx, query, append([]any{task.Date, task.Title, task.Comment, task.Repeat,
+			task.Priority, task.Time, project, updatedAt()}, args...)...)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err = result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return checkVersion(ctx, tx, userID, id, task.Version)
+		}
+		if err := setTaskTags(ctx, tx, userID, id, task.Tags); err != nil {
+			return err
+		}
+		return s.dialect.indexTask(tx, id, task.Title, task.Comment)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
+// DeleteTask перемещает задачу пользователя в корзину. Задача остаётся в поисковом
+// индексе, чтобы её можно было восстановить, и удаляется окончательно при очистке корзины.
+func (s *SQLStore) DeleteTask(ctx context.Context, userID, id int64) (int64, error) {
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(
+		"UPDATE scheduler SET deleted_at = ?, version = version + 1, updated_at = ? WHERE id = ? AND user_id = ? AND deleted_at = ''"),
+		time.Now().UTC().Format(time.RFC3339), updatedAt(), id, userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListTrash возвращает задачи пользователя из корзины, начиная с удалённых последними.
+func (s *SQLStore) ListTrash(ctx context.Context, userID int64) ([]models.Task, error) {
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(`
+		SELECT `+taskColumns+`, deleted_at FROM scheduler
+		WHERE user_id = ? AND deleted_at <> ''
+		ORDER BY deleted_at DESC, id DESC
+	`), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		var deletedAt string
+		task, err := scanTask(rows, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		task.DeletedAt = deletedAt
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, loadTags(ctx, s.db, tasks)
+}
+
+// RestoreTask возвращает задачу пользователя из корзины.
+func (s *SQLStore) RestoreTask(ctx context.Context, userID, id int64) (int64, error) {
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(
+		"UPDATE scheduler SET deleted_at = '', version = version + 1, updated_at = ? WHERE id = ? AND user_id = ? AND deleted_at <> ''"),
+		updatedAt(), id, userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PurgeTrash окончательно удаляет задачи всех пользователей, попавшие в корзину раньше before.
+func (s *SQLStore) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		var ids []int64
+		err := tx.SelectContext(ctx, &ids, tx.Rebind(
+			"DELETE FROM scheduler WHERE deleted_at <> '' AND deleted_at < ? RETURNING id"),
+			before.UTC().Format(time.RFC3339),
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := deleteTaskTags(ctx, tx, id); err != nil {
+				return err
+			}
+			if err := s.dialect.unindexTask(tx, id); err != nil {
+				return err
+			}
+		}
+		purged = int64(len(ids))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// CountTasks возвращает число задач всех пользователей в каждом состоянии на дату today.
+// Состояния без задач тоже попадают в результат.
+func (s *SQLStore) CountTasks(ctx context.Context, today string) (map[string]int64, error) {
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(`
+		SELECT CASE
+			WHEN deleted_at <> '' THEN '`+TaskStateTrash+`'
+			WHEN date < ? THEN '`+TaskStateOverdue+`'
+			ELSE '`+TaskStateActive+`'
+		END AS state, count(*)
+		FROM scheduler GROUP BY state
+	`), today)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int64{TaskStateActive: 0, TaskStateOverdue: 0, TaskStateTrash: 0}
+	for rows.Next() {
+		var state string
+		var count int64
+		if err := rows.Scan(&state, &count); err != nil {
+			return nil, err
+		}
+		counts[state] = count
+	}
+	return counts, rows.Err()
+}
+
+// ListTasks возвращает задачи пользователя по условиям фильтра:
+// на указанную дату, найденные по словам или ближайшие по дате,
+// с учётом проекта и меток.
+func (s *SQLStore) ListTasks(ctx context.Context, userID int64, filter TaskFilter) ([]models.Task, error) {
+	where := []string{"user_id = ?", "deleted_at = ''"}
+	args := []any{userID}
+
+	switch {
+	case filter.Date != "":
+		where = append(where, "date = ?")
+		args = append(args, filter.Date)
+	case filter.Search != "":
+		if cond, arg, ok := s.dialect.searchCondition(filter.Search); ok {
+			where = append(where, cond)
+			args = append(args, arg)
+		}
+	}
+	if filter.Until != "" {
+		where = append(where, "date <= ?")
+		args = append(args, filter.Until)
+	}
+	if filter.Project != "" {
+		where = append(where, "project_id IN (SELECT id FROM projects WHERE user_id = ? AND name = ?)")
+		args = append(args, userID, filter.Project)
+	}
+	for _, tag := range filter.Tags {
+		where = append(where, `id IN (
+			SELECT task_tags.task_id FROM task_tags JOIN tags ON tags.id = task_tags.tag_id
+			WHERE tags.user_id = ? AND tags.name = ?)`)
+		args = append(args, userID, tag)
+	}
+
+	if filter.After != nil {
+		cond, condArgs := afterCondition(*filter.After)
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+
+	query := "SELECT " + taskColumns + " FROM scheduler WHERE " +
+		strings.Join(where, " AND ") + " ORDER BY " + taskOrder
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, loadTags(ctx, s.db, tasks)
+}
+
+// taskOrder порядок задач в списке: по дате, затем по времени (задачи без времени
+// в конце дня), по убыванию приоритета и по ID
+const taskOrder = "date, time = '', time, priority DESC, id"
+
+// afterCondition возвращает условие на задачи, которые в порядке taskOrder идут после cursor.
+// Сравнение раскрыто по полям, потому что направления сортировки у них разные.
+func afterCondition(cursor TaskCursor) (string, []any) {
+	if cursor.Time == "" {
+		// После задачи без времени идут только задачи без времени того же дня
+		return `(date > ? OR (date = ? AND time = '' AND (priority < ? OR (priority = ? AND id > ?))))`,
+			[]any{cursor.Date, cursor.Date, cursor.Priority, cursor.Priority, cursor.ID}
+	}
+	return `(date > ? OR (date = ? AND (time = '' OR time > ? OR (time = ? AND (priority < ? OR (priority = ? AND id > ?))))))`,
+		[]any{cursor.Date, cursor.Date, cursor.Time, cursor.Time, cursor.Priority, cursor.Priority, cursor.ID}
+}
+
+// versionCondition возвращает условие на задачу пользователя вне корзины,
+// а если version не 0 - ещё и на её версию
+func versionCondition(id, userID, version int64) (string, []any) {
+	cond := "id = ? AND user_id = ? AND deleted_at = ''"
+	args := []any{id, userID}
+	if version != 0 {
+		cond += " AND version = ?"
+		args = append(args, version)
+	}
+	return cond, args
+}
+
+// checkVersion объясняет, почему запрос с условием versionCondition не изменил ни одной
+// строки: если задача есть, значит, её версия другая. Если задачи нет, возвращается nil,
+// и вызывающий сообщает об этом сам.
+func checkVersion(ctx context.Context, tx *sqlx.Tx, userID, id, version int64) error {
+	if version == 0 {
+		return nil
+	}
+	var exists bool
+	err := tx.QueryRowContext(ctx, tx.Rebind(
+		"SELECT EXISTS (SELECT 1 FROM scheduler WHERE id = ? AND user_id = ? AND deleted_at = '')"),
+		id, userID,
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// updatedAt возвращает время изменения задачи для столбца updated_at
+func updatedAt() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// taskColumns столбцы задачи в порядке, который ожидает scanTask
+const taskColumns = "id, date, title, comment, repeat, priority, time, version, updated_at, " +
+	"COALESCE((SELECT name FROM projects WHERE projects.id = scheduler.project_id), '')"
+
+// scanner общий интерфейс *sql.Row и *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask читает задачу из строки результата запроса со столбцами taskColumns,
+// следующие за ними столбцы читаются в extra.
+func scanTask(row scanner, extra ...any) (*models.Task, error) {
+	var task models.Task
+	var id int64
+	dest := append([]any{&id, &task.Date, &task.Title, &task.Comment, &task.Repeat,
+		&task.Priority, &task.Time, &task.Version, &task.UpdatedAt, &task.Project}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	task.ID = strconv.FormatInt(id, 10)
+	return &task, nil
+}
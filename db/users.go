@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go_final_project/models"
+)
+
+// ErrUserExists возвращается при регистрации занятого логина.
+var ErrUserExists = errors.New("user already exists")
+
+// ErrUserNotFound возвращается, если пользователь не найден.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrFeedTokenNotFound возвращается, если токен подписки на календарь не выдавался или отозван.
+var ErrFeedTokenNotFound = errors.New("feed token not found")
+
+// TokenSecret возвращает ключ подписи токенов, при первом вызове
+// генерирует его и сохраняет в базе.
+func (s *SQLStore) TokenSecret(ctx context.Context) (string, error) {
+	query := "SELECT value FROM settings WHERE key = 'jwt_secret'"
+
+	var secret string
+	err := s.db.QueryRowContext(ctx, query).Scan(&secret)
+	if err == nil {
+		return secret, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	_, err = s.db.ExecContext(ctx, s.db.Rebind(
+		"INSERT INTO settings (key, value) VALUES ('jwt_secret', ?) ON CONFLICT DO NOTHING"),
+		hex.EncodeToString(buf),
+	)
+	if err != nil {
+		return "", err
+	}
+	// Перечитываем на случай, если ключ одновременно записал другой процесс
+	err = s.db.QueryRowContext(ctx, query).Scan(&secret)
+	return secret, err
+}
+
+// AddUser создаёт пользователя и возвращает его ID.
+func (s *SQLStore) AddUser(ctx context.Context, login, passwordHash string) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, s.db.Rebind(
+		"INSERT INTO users (login, password_hash, created_at) VALUES (?, ?, ?) RETURNING id"),
+		login, passwordHash, time.Now().UTC().Format(time.RFC3339),
+	).Scan(&id)
+	if err != nil {
+		if s.dialect.isUniqueViolation(err) {
+			return 0, ErrUserExists
+		}
+		slog.DebugContext(ctx, "failed to insert user", "error", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetUserByLogin возвращает пользователя по логину.
+func (s *SQLStore) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	return s.getUser(ctx, "SELECT id, login, password_hash FROM users WHERE login = ?", login)
+}
+
+// GetUserByID возвращает пользователя по ID.
+func (s *SQLStore) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	return s.getUser(ctx, "SELECT id, login, password_hash FROM users WHERE id = ?", id)
+}
+
+func (s *SQLStore) getUser(ctx context.Context, query string, arg any) (*models.User, error) {
+	var user models.User
+	err := s.db.QueryRowContext(ctx, s.db.Rebind(query), arg).Scan(&user.ID, &user.Login, &user.PasswordHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResetFeedToken выдаёт пользователю новый токен подписки на календарь.
+// Прежний токен перестаёт действовать. В базе хранится только хеш токена.
+func (s *SQLStore) ResetFeedToken(ctx context.Context, userID int64) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(`
+		INSERT INTO feed_tokens (user_id, token_hash, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = excluded.token_hash, created_at = excluded.created_at
+	`), userID, feedTokenHash(token), time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeFeedToken отзывает токен подписки на календарь пользователя.
+func (s *SQLStore) RevokeFeedToken(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM feed_tokens WHERE user_id = ?"), userID)
+	return err
+}
+
+// FeedTokenUser возвращает ID пользователя, которому выдан токен подписки на календарь.
+func (s *SQLStore) FeedTokenUser(ctx context.Context, token string) (int64, error) {
+	var userID int64
+	err := s.db.QueryRowContext(ctx, s.db.Rebind(
+		"SELECT user_id FROM feed_tokens WHERE token_hash = ?"), feedTokenHash(token),
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrFeedTokenNotFound
+	}
+	return userID, err
+}
+
+// feedTokenHash возвращает хеш токена подписки. Токен случайный и длинный,
+// поэтому соль не нужна, а поиск по хешу остаётся простым.
+func feedTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
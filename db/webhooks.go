@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"go_final_project/models"
+)
+
+// AddWebhook создаёт подписку пользователя и возвращает её ID.
+func (s *SQLStore) AddWebhook(ctx context.Context, userID int64, webhook models.Webhook) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, s.db.Rebind(`
+		INSERT INTO webhooks (user_id, url, secret, events, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`), userID, webhook.URL, webhook.Secret, strings.Join(webhook.Events, ","), webhook.CreatedAt).Scan(&id)
+	return id, err
+}
+
+// ListWebhooks возвращает подписки пользователя без ключей подписи.
+func (s *SQLStore) ListWebhooks(ctx context.Context, userID int64) ([]models.Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(
+		"SELECT id, url, events, created_at FROM webhooks WHERE user_id = ? ORDER BY id"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		var webhook models.Webhook
+		var id int64
+		var events string
+		if err := rows.Scan(&id, &webhook.URL, &events, &webhook.CreatedAt); err != nil {
+			return nil, err
+		}
+		webhook.ID = strconv.FormatInt(id, 10)
+		if events != "" {
+			webhook.Events = strings.Split(events, ",")
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook удаляет подписку пользователя вместе с её доставками.
+func (s *SQLStore) DeleteWebhook(ctx context.Context, userID, id int64) (int64, error) {
+	var rowsAffected int64
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, tx.Rebind("DELETE FROM webhooks WHERE id = ? AND user_id = ?"), id, userID)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err = result.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, tx.Rebind("DELETE FROM webhook_deliveries WHERE webhook_id = ?"), id)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
+// EnqueueDeliveries ставит событие в очередь доставки всем подпискам пользователя на этот тип событий
+// и возвращает число созданных доставок.
+func (s *SQLStore) EnqueueDeliveries(ctx context.Context, userID int64, event string, payload []byte) (int64, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at, created_at)
+		SELECT id, ?, ?, ?, ?, ? FROM webhooks
+		WHERE user_id = ? AND (events = '' OR ',' || events || ',' LIKE ?)
+	`), event, string(payload), models.DeliveryPending, now, now, userID, "%,"+event+",%")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DueDeliveries возвращает доставки, время очередной попытки которых наступило к now.
+func (s *SQLStore) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return s.listDeliveries(ctx, `
+		WHERE d.status = ? AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at, d.id LIMIT ?
+	`, models.DeliveryPending, now.UTC().Format(time.RFC3339), limit)
+}
+
+// ListDeadDeliveries возвращает доставки пользователя, от которых отказались после всех попыток.
+func (s *SQLStore) ListDeadDeliveries(ctx context.Context, userID int64) ([]models.WebhookDelivery, error) {
+	return s.listDeliveries(ctx, `
+		WHERE d.status = ? AND w.user_id = ?
+		ORDER BY d.id DESC
+	`, models.DeliveryDead, userID)
+}
+
+func (s *SQLStore) listDeliveries(ctx context.Context, where string, args ...any) ([]models.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(`
+		SELECT d.id, d.webhook_id, w.url, w.secret, d.event, d.payload, d.status,
+			d.attempts, d.next_attempt_at, d.last_error, d.created_at
+		FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+	`+where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var id, webhookID int64
+		var payload string
+		err := rows.Scan(&id, &webhookID, &d.URL, &d.Secret, &d.Event, &payload, &d.Status,
+			&d.Attempts, &d.NextAttemptAt, &d.LastError, &d.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		d.ID = strconv.FormatInt(id, 10)
+		d.WebhookID = strconv.FormatInt(webhookID, 10)
+		d.Payload = []byte(payload)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// UpdateDelivery сохраняет результат попытки доставки: статус, число попыток,
+// время следующей попытки и текст ошибки.
+func (s *SQLStore) UpdateDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	id, err := strconv.ParseInt(delivery.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.db.Rebind(`
+		UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?
+		WHERE id = ?
+	`), delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, id)
+	return err
+}
+
+// RetryDelivery возвращает доставку пользователя из списка недоставленных в очередь.
+func (s *SQLStore) RetryDelivery(ctx context.Context, userID, id int64) (int64, error) {
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(`
+		UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND status = ? AND webhook_id IN (SELECT id FROM webhooks WHERE user_id = ?)
+	`), models.DeliveryPending, time.Now().UTC().Format(time.RFC3339), id, models.DeliveryDead, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PurgeDeliveries удаляет доставленные события, созданные раньше before.
+// Недоставленные события остаются, чтобы их можно было вернуть в очередь.
+func (s *SQLStore) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(`
+		DELETE FROM webhook_deliveries WHERE status = ? AND created_at < ?
+	`), models.DeliveryDelivered, before.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
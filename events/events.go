@@ -0,0 +1,95 @@
+// Package events рассылает события об изменении задач подписчикам внутри сервера.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go_final_project/models"
+)
+
+// Типы событий
+const (
+	TaskCreated = "task.created"
+	TaskUpdated = "task.updated"
+	TaskDone    = "task.done"
+	TaskDeleted = "task.deleted"
+)
+
+// Types все типы событий
+var Types = []string{TaskCreated, TaskUpdated, TaskDone, TaskDeleted}
+
+// Event событие об изменении задачи
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"event"`
+	UserID    int64       `json:"-"`
+	CreatedAt string      `json:"created_at"`
+	Task      models.Task `json:"task"`
+	NextDate  string      `json:"next_date,omitempty"` // для task.done повторяющейся задачи
+}
+
+// New создаёт событие с уникальным ID и текущим временем
+func New(eventType string, userID int64, task models.Task) Event {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return Event{
+		ID:        hex.EncodeToString(buf),
+		Type:      eventType,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Task:      task,
+	}
+}
+
+// Bus рассылает события всем подписчикам. Публикация не блокируется:
+// если подписчик не успевает читать, событие для него теряется.
+type Bus struct {
+	mu   sync.RWMutex
+	next int
+	subs map[int]chan Event
+}
+
+// NewBus создаёт шину без подписчиков
+func NewBus() *Bus {
+	return &Bus{subs: map[int]chan Event{}}
+}
+
+// Publish отправляет событие подписчикам
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			slog.Warn("subscriber is too slow, event dropped", "event", event.Type, "event_id", event.ID)
+		}
+	}
+}
+
+// Subscribe возвращает канал событий с буфером buffer и функцию отмены подписки,
+// которая закрывает канал.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	ch := make(chan Event, buffer)
+	b.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs, id)
+			close(ch)
+		})
+	}
+}
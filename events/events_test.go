@@ -0,0 +1,33 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go_final_project/models"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	first, cancelFirst := bus.Subscribe(1)
+	second, cancelSecond := bus.Subscribe(1)
+	defer cancelSecond()
+
+	event := New(TaskCreated, 7, models.Task{ID: "1", Title: "Задача"})
+	bus.Publish(event)
+	assert.Equal(t, event, <-first)
+	assert.Equal(t, event, <-second)
+
+	// Отменённая подписка закрывает канал и больше не получает событий
+	cancelFirst()
+	cancelFirst()
+	_, ok := <-first
+	assert.False(t, ok)
+
+	// Переполненный буфер не блокирует публикацию
+	bus.Publish(New(TaskUpdated, 7, models.Task{ID: "1"}))
+	bus.Publish(New(TaskDeleted, 7, models.Task{ID: "1"}))
+	assert.Equal(t, TaskUpdated, (<-second).Type)
+	assert.Len(t, second, 0)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go_final_project/auth"
+	"go_final_project/constants"
+	"go_final_project/db"
+	"go_final_project/i18n"
+	"go_final_project/utils"
+)
+
+const (
+	defaultAgendaDays = 30  // период повестки по умолчанию
+	maxAgendaDays     = 366 // наибольший период повестки
+)
+
+// AgendaItem одно повторение задачи в повестке
+type AgendaItem struct {
+	TaskID     string   `json:"task_id"`
+	Date       string   `json:"date"`
+	Time       string   `json:"time,omitempty"`
+	Title      string   `json:"title"`
+	Comment    string   `json:"comment,omitempty"`
+	Repeat     string   `json:"repeat,omitempty"`
+	RepeatText string   `json:"repeat_text,omitempty"`
+	Priority   int      `json:"priority,omitempty"`
+	Project    string   `json:"project,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// AgendaResponse структура ответа с повесткой
+type AgendaResponse struct {
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Items []AgendaItem `json:"items"`
+}
+
+// HandleAgenda возвращает все повторения задач в интервале from - to (YYYYMMDD, включительно).
+// По умолчанию интервал начинается сегодня и длится 30 дней. Фильтры project и tag
+// работают так же, как в /api/tasks.
+func (h *Handler) HandleAgenda(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	from := utils.NormalizeDate(time.Now())
+	if value := r.URL.Query().Get("from"); value != "" {
+		date, err := time.ParseInLocation(constants.DateFormat, value, time.Local)
+		if err != nil {
+			writeError(w, r, invalidParam("from", i18n.ParamDateInvalid, "from"))
+			return
+		}
+		from = date
+	}
+	to := from.AddDate(0, 0, defaultAgendaDays-1)
+	if value := r.URL.Query().Get("to"); value != "" {
+		date, err := time.ParseInLocation(constants.DateFormat, value, time.Local)
+		if err != nil {
+			writeError(w, r, invalidParam("to", i18n.ParamDateInvalid, "to"))
+			return
+		}
+		to = date
+	}
+	if to.Before(from) {
+		writeError(w, r, invalidParam("to", i18n.PeriodReversed))
+		return
+	}
+	if to.After(from.AddDate(0, 0, maxAgendaDays-1)) {
+		writeError(w, r, invalidParam("to", i18n.PeriodTooLong, maxAgendaDays))
+		return
+	}
+
+	// Задачи с датой позже периода в него не попадут: повторения идут только вперёд
+	tasks, err := h.Store.ListTasks(r.Context(), auth.UserID(r.Context()), db.TaskFilter{
+		Until:   to.Format(constants.DateFormat),
+		Project: r.URL.Query().Get("project"),
+		Tags:    queryTags(r),
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list tasks for agenda", "error", err)
+		writeError(w, r, internalError(i18n.TasksListFailed))
+		return
+	}
+
+	lang := i18n.Lang(r.Context())
+	items := []AgendaItem{}
+	for _, task := range tasks {
+		start, err := time.ParseInLocation(constants.DateFormat, task.Date, time.Local)
+		if err != nil {
+			slog.WarnContext(r.Context(), "invalid task date", "task_id", task.ID, "date", task.Date)
+			continue
+		}
+
+		dates := []time.Time{start}
+		if task.Repeat != "" {
+			rule, err := utils.ParseRepeat(task.Repeat)
+			if err != nil {
+				slog.WarnContext(r.Context(), "invalid task repeat rule", "task_id", task.ID, "repeat", task.Repeat)
+				continue
+			}
+			dates = rule.Occurrences(start, from, to)
+		}
+
+		repeatText := i18n.DescribeRepeat(lang, task.Repeat)
+		for _, date := range dates {
+			if date.Before(from) || date.After(to) {
+				continue
+			}
+			items = append(items, AgendaItem{
+				TaskID:     task.ID,
+				Date:       date.Format(constants.DateFormat),
+				Time:       task.Time,
+				Title:      task.Title,
+				Comment:    task.Comment,
+				Repeat:     task.Repeat,
+				RepeatText: repeatText,
+				Priority:   task.Priority,
+				Project:    task.Project,
+				Tags:       task.Tags,
+			})
+		}
+	}
+
+	// Тот же порядок, что и в списке задач: дата, время (без времени - в конце), приоритет, ID
+	slices.SortStableFunc(items, func(a, b AgendaItem) int {
+		if c := cmp.Compare(a.Date, b.Date); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(agendaTimeKey(a.Time), agendaTimeKey(b.Time)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Priority, a.Priority); c != 0 {
+			return c
+		}
+		aID, _ := strconv.ParseInt(a.TaskID, 10, 64)
+		bID, _ := strconv.ParseInt(b.TaskID, 10, 64)
+		return cmp.Compare(aID, bID)
+	})
+
+	response := AgendaResponse{
+		From:  from.Format(constants.DateFormat),
+		To:    to.Format(constants.DateFormat),
+		Items: items,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// agendaTimeKey ставит задачи без времени после всех задач со временем
+func agendaTimeKey(t string) string {
+	if t == "" {
+		return "24:00"
+	}
+	return t
+}
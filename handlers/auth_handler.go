@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"go_final_project/auth"
+	"go_final_project/db"
+	"go_final_project/i18n"
+)
+
+// minPasswordLength минимальная длина пароля при регистрации
+const minPasswordLength = 8
+
+// signInRequest тело запроса на вход
+type signInRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// HandleSignIn проверяет общий пароль и возвращает токен
+func (h *Handler) HandleSignIn(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	var req signInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.DebugContext(r.Context(), "invalid JSON body", "error", err)
+		writeError(w, r, badRequest(i18n.InvalidJSON))
+		return
+	}
+
+	if !h.Auth.Enabled() || !h.Auth.CheckPassword(req.Password) {
+		slog.WarnContext(r.Context(), "failed sign-in attempt")
+		writeError(w, r, unauthorized(i18n.WrongPassword))
+		return
+	}
+
+	token, err := h.Auth.SharedToken()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to create token", "error", err)
+		writeError(w, r, internalError(i18n.TokenFailed))
+		return
+	}
+
+	writeToken(w, token)
+}
+
+// HandleRegister создаёт учётную запись и возвращает токен
+func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	if apiErr := h.checkRegistration(r); apiErr != nil {
+		slog.WarnContext(r.Context(), "registration denied", "mode", h.Registration)
+		writeError(w, r, apiErr)
+		return
+	}
+
+	var req signInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.DebugContext(r.Context(), "invalid JSON body", "error", err)
+		writeError(w, r, badRequest(i18n.InvalidJSON))
+		return
+	}
+
+	req.Login = strings.TrimSpace(req.Login)
+	if req.Login == "" {
+		writeError(w, r, invalidField("login", i18n.LoginRequired))
+		return
+	}
+	if utf8.RuneCountInString(req.Password) < minPasswordLength {
+		writeError(w, r, invalidField("password", i18n.PasswordTooShort, minPasswordLength))
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to hash password", "error", err)
+		writeError(w, r, internalError(i18n.RegisterFailed))
+		return
+	}
+
+	id, err := h.Store.AddUser(r.Context(), req.Login, string(hash))
+	if errors.Is(err, db.ErrUserExists) {
+		writeError(w, r, conflict(i18n.UserExists))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to register user", "login", req.Login, "error", err)
+		writeError(w, r, internalError(i18n.RegisterFailed))
+		return
+	}
+	slog.InfoContext(r.Context(), "user registered", "user_id", id)
+
+	token, err := h.Auth.NewToken(id, string(hash))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to create token", "error", err)
+		writeError(w, r, internalError(i18n.TokenFailed))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"id":    strconv.FormatInt(id, 10),
+		"token": token,
+	}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// checkRegistration проверяет, можно ли зарегистрировать пользователя. Если задан общий
+// пароль, по умолчанию это может только вошедший по нему: иначе любой получил бы
+// доступ к серверу, зарегистрировавшись сам.
+func (h *Handler) checkRegistration(r *http.Request) *APIError {
+	switch h.Registration {
+	case auth.RegistrationOpen:
+		return nil
+	case auth.RegistrationClosed:
+		return forbidden(i18n.RegisterClosed)
+	}
+	if !h.Auth.Enabled() {
+		return nil
+	}
+
+	token := tokenFromRequest(r)
+	if token == "" {
+		return unauthorized(i18n.RegisterDenied)
+	}
+	userID, err := h.authenticate(r.Context(), token)
+	if err != nil && !errors.Is(err, errTokenRejected) {
+		slog.ErrorContext(r.Context(), "failed to check token", "error", err)
+		return internalError(i18n.TokenCheckFailed)
+	}
+	if err != nil || userID != auth.SharedUserID {
+		return unauthorized(i18n.RegisterDenied)
+	}
+	return nil
+}
+
+// dummyPasswordHash хеш с той же стоимостью, что у паролей пользователей. Совпадение
+// с ним ничего не даёт: вход по неизвестному логину отклоняется в любом случае.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("unknown user"), bcrypt.DefaultCost)
+	return hash
+})
+
+// HandleLogin проверяет логин и пароль пользователя и возвращает токен
+func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	var req signInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.DebugContext(r.Context(), "invalid JSON body", "error", err)
+		writeError(w, r, badRequest(i18n.InvalidJSON))
+		return
+	}
+
+	user, err := h.Store.GetUserByLogin(r.Context(), strings.TrimSpace(req.Login))
+	if err != nil && !errors.Is(err, db.ErrUserNotFound) {
+		slog.ErrorContext(r.Context(), "failed to get user", "error", err)
+		writeError(w, r, internalError(i18n.SignInFailed))
+		return
+	}
+	// Для неизвестного логина пароль сравнивается с заготовленным хешем, чтобы по времени
+	// ответа нельзя было узнать, какие логины существуют
+	hash := dummyPasswordHash()
+	if user != nil {
+		hash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) != nil || user == nil {
+		slog.WarnContext(r.Context(), "failed sign-in attempt")
+		writeError(w, r, unauthorized(i18n.WrongCredentials))
+		return
+	}
+
+	token, err := h.Auth.NewToken(user.ID, user.PasswordHash)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to create token", "error", err)
+		writeError(w, r, internalError(i18n.TokenFailed))
+		return
+	}
+
+	writeToken(w, token)
+}
+
+// RequireAuth определяет пользователя по токену и сохраняет его в контексте запроса.
+// Запрос без токена допускается только при отключённой аутентификации
+// и выполняется от имени общего пользователя.
+func (h *Handler) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := tokenFromRequest(r)
+		if token == "" {
+			if h.Auth.Enabled() {
+				writeError(w, r, unauthorized(i18n.AuthRequired))
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		userID, err := h.authenticate(r.Context(), token)
+		if err != nil && !errors.Is(err, errTokenRejected) {
+			slog.ErrorContext(r.Context(), "failed to check token", "error", err)
+			writeError(w, r, internalError(i18n.TokenCheckFailed))
+			return
+		}
+		if err != nil {
+			slog.WarnContext(r.Context(), "token rejected", "error", err)
+			writeError(w, r, unauthorized(i18n.AuthRequired))
+			return
+		}
+
+		next(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
+	}
+}
+
+// errTokenRejected оборачивает ошибки authenticate, вызванные самим токеном,
+// чтобы отличить их от сбоев базы данных
+var errTokenRejected = errors.New("token rejected")
+
+// authenticate проверяет токен и возвращает идентификатор пользователя
+func (h *Handler) authenticate(ctx context.Context, token string) (int64, error) {
+	claims, err := h.Auth.Parse(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", errTokenRejected, err)
+	}
+	if claims.UserID == auth.SharedUserID {
+		return auth.SharedUserID, nil
+	}
+
+	user, err := h.Store.GetUserByID(ctx, claims.UserID)
+	if errors.Is(err, db.ErrUserNotFound) {
+		return 0, fmt.Errorf("%w: %w", errTokenRejected, err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !h.Auth.Matches(claims, user.PasswordHash) {
+		return 0, fmt.Errorf("%w: %w", errTokenRejected, auth.ErrPasswordChanged)
+	}
+	return user.ID, nil
+}
+
+// tokenFromRequest достаёт токен из cookie token или заголовка Authorization.
+// В адресе токен не принимается: он попадает в журналы и историю браузера,
+// для календарей есть отдельный токен подписки (см. RequireFeedAuth)
+func tokenFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie("token"); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return ""
+}
+
+// writeToken отправляет токен в формате JSON
+func writeToken(w http.ResponseWriter, token string) {
+	if err := json.NewEncoder(w).Encode(map[string]any{"token": token}); err != nil {
+		slog.Error("failed to write response", "error", err)
+	}
+}
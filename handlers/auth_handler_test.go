@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"go_final_project/auth"
+	"go_final_project/db"
+)
+
+// testSecret ключ подписи токенов в тестах
+const testSecret = "test-signing-key"
+
+// openTestStore создаёт базу SQLite во временном каталоге
+func openTestStore(t *testing.T) *db.SQLStore {
+	t.Helper()
+	store, err := db.Open("", filepath.Join(t.TempDir(), "scheduler.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	require.NoError(t, store.Migrate())
+	return store
+}
+
+// testServer возвращает маршруты API для хранилища store и общего пароля password
+func testServer(store db.Store, password string) http.Handler {
+	return routes(NewHandler(store, auth.New(password, testSecret), nil))
+}
+
+// routes возвращает маршруты API обработчика h
+func routes(h *Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/signin", h.HandleSignIn)
+	mux.HandleFunc("/api/register", h.HandleRegister)
+	mux.HandleFunc("/api/login", h.HandleLogin)
+	mux.HandleFunc("/api/task", h.RequireAuth(h.HandleTask))
+	mux.HandleFunc("/api/tasks", h.RequireAuth(h.HandleTaskList))
+	mux.HandleFunc("/api/calendar.ics", h.RequireFeedAuth(h.HandleCalendar))
+	mux.HandleFunc("/api/calendar/token", h.RequireAuth(h.HandleFeedToken))
+	return mux
+}
+
+// call выполняет запрос к server с токеном token (пусто - без токена)
+// и возвращает код ответа и разобранное тело
+func call(t *testing.T, server http.Handler, method, target, token string, body any) (int, map[string]any) {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req := httptest.NewRequest(method, target, bytes.NewReader(data))
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	}
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	var ret map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &ret)
+	return rec.Code, ret
+}
+
+func TestSignIn(t *testing.T) {
+	store := openTestStore(t)
+	server := testServer(store, "secret")
+
+	status, ret := call(t, server, http.MethodPost, "/api/signin", "", map[string]any{"password": "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, ErrCodeUnauthorized, ret["code"])
+
+	status, ret = call(t, server, http.MethodPost, "/api/signin", "", map[string]any{"password": "secret"})
+	require.Equal(t, http.StatusOK, status)
+	token, _ := ret["token"].(string)
+	require.NotEmpty(t, token)
+
+	status, ret = call(t, server, http.MethodGet, "/api/tasks", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, ErrCodeUnauthorized, ret["code"])
+	status, _ = call(t, server, http.MethodGet, "/api/tasks", "not-a-token", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = call(t, server, http.MethodGet, "/api/tasks", token, nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	// После смены пароля выданные токены не действуют
+	changed := testServer(store, "new-secret")
+	status, _ = call(t, changed, http.MethodGet, "/api/tasks", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = call(t, changed, http.MethodPost, "/api/signin", "", map[string]any{"password": "secret"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestAuthDisabled(t *testing.T) {
+	server := testServer(openTestStore(t), "")
+
+	status, _ := call(t, server, http.MethodGet, "/api/tasks", "", nil)
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = call(t, server, http.MethodPost, "/api/signin", "", map[string]any{"password": ""})
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestRegistration(t *testing.T) {
+	store := openTestStore(t)
+	server := testServer(store, "secret")
+	user := func(login string) map[string]any {
+		return map[string]any{"login": login, "password": "long-password"}
+	}
+
+	// С общим паролем сам зарегистрироваться нельзя
+	status, ret := call(t, server, http.MethodPost, "/api/register", "", user("anna"))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, ErrCodeUnauthorized, ret["code"])
+
+	_, ret = call(t, server, http.MethodPost, "/api/signin", "", map[string]any{"password": "secret"})
+	shared, _ := ret["token"].(string)
+	require.NotEmpty(t, shared)
+	status, ret = call(t, server, http.MethodPost, "/api/register", shared, user("anna"))
+	require.Equal(t, http.StatusOK, status)
+	token, _ := ret["token"].(string)
+	require.NotEmpty(t, token)
+
+	// Зарегистрированный пользователь работает со своими задачами, но не регистрирует других
+	status, _ = call(t, server, http.MethodGet, "/api/tasks", token, nil)
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = call(t, server, http.MethodPost, "/api/register", token, user("boris"))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	h := NewHandler(store, auth.New("secret", testSecret), nil)
+	h.Registration = auth.RegistrationOpen
+	status, _ = call(t, routes(h), http.MethodPost, "/api/register", "", user("boris"))
+	assert.Equal(t, http.StatusOK, status)
+
+	h.Registration = auth.RegistrationClosed
+	status, ret = call(t, routes(h), http.MethodPost, "/api/register", shared, user("vera"))
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, ErrCodeForbidden, ret["code"])
+}
+
+func TestLoginUnknownUser(t *testing.T) {
+	h := NewHandler(openTestStore(t), auth.New("secret", testSecret), nil)
+	h.Registration = auth.RegistrationOpen
+	server := routes(h)
+	status, _ := call(t, server, http.MethodPost, "/api/register", "",
+		map[string]any{"login": "anna", "password": "long-password"})
+	require.Equal(t, http.StatusOK, status)
+
+	// Неизвестный логин проверяется так же долго и отклоняется так же, как неверный пароль
+	cost, err := bcrypt.Cost(dummyPasswordHash())
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.DefaultCost, cost)
+
+	status, wrong := call(t, server, http.MethodPost, "/api/login", "",
+		map[string]any{"login": "anna", "password": "wrong-password"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, unknown := call(t, server, http.MethodPost, "/api/login", "",
+		map[string]any{"login": "boris", "password": "unknown user"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, wrong, unknown)
+
+	status, _ = call(t, server, http.MethodPost, "/api/login", "",
+		map[string]any{"login": "anna", "password": "long-password"})
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"go_final_project/i18n"
 	"go_final_project/utils"
 )
 
@@ -15,13 +18,18 @@ func HandleDate(w http.ResponseWriter, r *http.Request) {
 
 	now, err := time.Parse("20060102", nowStr)
 	if err != nil {
-		http.Error(w, "Invalid now parameter", http.StatusBadRequest)
+		writeError(w, r, invalidParam("now", i18n.ParamDateInvalid, "now"))
 		return
 	}
 
 	nextDate, err := utils.NextDate(now, dateStr, repeat)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.DebugContext(r.Context(), "failed to calculate next date", "date", dateStr, "repeat", repeat, "error", err)
+		if errors.Is(err, utils.ErrInvalidDate) {
+			writeError(w, r, invalidParam("date", i18n.DateInvalid))
+		} else {
+			writeError(w, r, invalidParam("repeat", i18n.RepeatInvalid))
+		}
 		return
 	}
 
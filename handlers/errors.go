@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go_final_project/i18n"
+	"go_final_project/logging"
+)
+
+// Коды ошибок API. Клиенты различают ошибки по коду: он не меняется,
+// даже если меняется текст сообщения.
+const (
+	ErrCodeBadRequest       = "bad_request"         // тело запроса не удалось разобрать
+	ErrCodeInvalidParameter = "invalid_parameter"   // параметр адреса не указан или неверен
+	ErrCodeValidation       = "validation_failed"   // поле задачи или подписки не прошло проверку
+	ErrCodeUnauthorized     = "unauthorized"        // требуется вход или неверные учётные данные
+	ErrCodeForbidden        = "forbidden"           // действие запрещено настройками сервера
+	ErrCodeNotFound         = "not_found"           // объект не найден
+	ErrCodeMethodNotAllowed = "method_not_allowed"  // метод не поддерживается адресом
+	ErrCodeConflict         = "conflict"            // объект уже существует или изменён
+	ErrCodePrecondition     = "precondition_failed" // версия объекта не совпала с If-Match
+	ErrCodeTooLarge         = "request_too_large"   // тело запроса больше допустимого
+	ErrCodeInternal         = "internal"            // ошибка сервера, подробности в журнале
+	ErrCodeNotImplemented   = "not_implemented"     // возможность недоступна на этом сервере
+	ErrCodeUnavailable      = "unavailable"         // сервер временно не может обработать запрос
+)
+
+// APIError ошибка, которую обработчик отправляет клиенту: код ответа,
+// постоянный код ошибки и сообщение из каталога i18n, которое переводится
+// на язык запроса при отправке.
+type APIError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"error"`
+	Field   string `json:"field,omitempty"` // поле тела или параметр адреса, к которому относится ошибка
+
+	key  i18n.Key
+	args []any
+}
+
+// Error возвращает сообщение на русском языке
+func (e *APIError) Error() string {
+	return i18n.T(i18n.RU, e.key, e.args...)
+}
+
+// newError создаёт ошибку с сообщением key из каталога i18n
+func newError(status int, code string, key i18n.Key, args ...any) *APIError {
+	return &APIError{Status: status, Code: code, key: key, args: args}
+}
+
+// errMethodNotAllowed ответ на запрос с неподдерживаемым методом
+var errMethodNotAllowed = newError(http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, i18n.MethodNotAllowed)
+
+// badRequest тело запроса не удалось разобрать
+func badRequest(key i18n.Key, args ...any) *APIError {
+	return newError(http.StatusBadRequest, ErrCodeBadRequest, key, args...)
+}
+
+// invalidParam параметр адреса name не указан или неверен
+func invalidParam(name string, key i18n.Key, args ...any) *APIError {
+	err := newError(http.StatusBadRequest, ErrCodeInvalidParameter, key, args...)
+	err.Field = name
+	return err
+}
+
+// invalidField поле name тела запроса не прошло проверку
+func invalidField(name string, key i18n.Key, args ...any) *APIError {
+	err := newError(http.StatusUnprocessableEntity, ErrCodeValidation, key, args...)
+	err.Field = name
+	return err
+}
+
+func unauthorized(key i18n.Key) *APIError {
+	return newError(http.StatusUnauthorized, ErrCodeUnauthorized, key)
+}
+
+func forbidden(key i18n.Key) *APIError {
+	return newError(http.StatusForbidden, ErrCodeForbidden, key)
+}
+
+func notFound(key i18n.Key) *APIError {
+	return newError(http.StatusNotFound, ErrCodeNotFound, key)
+}
+
+func conflict(key i18n.Key) *APIError {
+	return newError(http.StatusConflict, ErrCodeConflict, key)
+}
+
+func preconditionFailed(key i18n.Key) *APIError {
+	return newError(http.StatusPreconditionFailed, ErrCodePrecondition, key)
+}
+
+// internalError ошибка сервера. Причину нужно записать в журнал до вызова,
+// клиенту отправляется только сообщение.
+func internalError(key i18n.Key) *APIError {
+	return newError(http.StatusInternalServerError, ErrCodeInternal, key)
+}
+
+// writeError отправляет ошибку в формате JSON на языке запроса:
+// {"error": "сообщение", "code": "код", "field": "поле"}.
+// Сообщение попадает в запись журнала о запросе, отдельно его записывать не нужно.
+func writeError(w http.ResponseWriter, r *http.Request, err *APIError) {
+	response := *err
+	response.Message = i18n.T(i18n.Lang(r.Context()), err.key, err.args...)
+	logging.RecordError(w, response.Message)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// taskIDParam возвращает идентификатор задачи из параметра id
+func taskIDParam(r *http.Request) (int64, *APIError) {
+	return idParam(r, "id", i18n.TaskIDRequired, i18n.TaskIDInvalid)
+}
+
+// idParam возвращает числовой идентификатор из параметра name
+func idParam(r *http.Request, name string, missing, invalid i18n.Key) (int64, *APIError) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, invalidParam(name, missing)
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, invalidParam(name, invalid)
+	}
+	return id, nil
+}
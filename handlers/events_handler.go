@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go_final_project/auth"
+	"go_final_project/i18n"
+)
+
+const (
+	streamBuffer    = 16               // сколько событий ждут отправки клиенту
+	streamHeartbeat = 30 * time.Second // как часто отправлять комментарий, чтобы прокси не закрыли соединение
+)
+
+// HandleEvents передаёт события об изменении задач пользователя
+// в формате Server-Sent Events, пока клиент не закроет соединение.
+func (h *Handler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok || h.Events == nil {
+		writeError(w, r, newError(http.StatusNotImplemented, ErrCodeNotImplemented, i18n.EventsNotSupported))
+		return
+	}
+
+	// Поток не ограничивается таймаутом записи сервера
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	userID := auth.UserID(r.Context())
+	ch, cancel := h.Events.Subscribe(streamBuffer)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.streams:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if event.UserID != userID {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "failed to encode event", "event", event.Type, "error", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -1,13 +1,59 @@
 package handlers
 
-import "database/sql"
+import (
+	"context"
+	"sync"
+
+	"go_final_project/auth"
+	"go_final_project/db"
+	"go_final_project/events"
+	"go_final_project/models"
+	"go_final_project/webhooks"
+)
 
 // Handler - структура для хранения зависимостей обработчиков
 type Handler struct {
-	DB *sql.DB
+	Store  db.Store
+	Auth   *auth.Auth
+	Events *events.Bus // nil - события не публикуются
+
+	// Webhooks ставит события в очередь вебхуков и проверяет адреса подписок,
+	// nil - события подписчикам не отправляются
+	Webhooks *webhooks.Dispatcher
+
+	Registration string // режим регистрации auth.Registration*, пусто - RegistrationPassword
+
+	streams     chan struct{} // закрывается при остановке сервера
+	closeStream sync.Once
 }
 
 // NewHandler создаёт новый экземпляр Handler
-func NewHandler(db *sql.DB) *Handler {
-	return &Handler{DB: db}
+func NewHandler(store db.Store, a *auth.Auth, bus *events.Bus) *Handler {
+	return &Handler{Store: store, Auth: a, Events: bus, streams: make(chan struct{})}
+}
+
+// CloseStreams завершает открытые потоки событий. Без этого остановка сервера
+// ждала бы, пока клиенты сами закроют бесконечные ответы /api/events.
+func (h *Handler) CloseStreams() {
+	if h.streams == nil {
+		return
+	}
+	h.closeStream.Do(func() { close(h.streams) })
+}
+
+// publish отправляет событие об изменении задачи пользователя. В очередь вебхуков
+// событие записывается сразу, до ответа клиенту, а не через шину: шина теряет
+// события, если подписчик не успевает их читать.
+func (h *Handler) publish(ctx context.Context, eventType string, userID int64, task models.Task) {
+	h.publishEvent(ctx, events.New(eventType, userID, task))
+}
+
+// publishEvent отправляет подготовленное событие, см. publish
+func (h *Handler) publishEvent(ctx context.Context, event events.Event) {
+	if h.Webhooks != nil {
+		h.Webhooks.Enqueue(ctx, event)
+	}
+	if h.Events != nil {
+		h.Events.Publish(event)
+	}
 }
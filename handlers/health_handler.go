@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go_final_project/i18n"
+)
+
+// readyTimeout сколько ждать ответа базы данных при проверке готовности
+const readyTimeout = 2 * time.Second
+
+// HandleHealth сообщает, что процесс запущен и отвечает на запросы.
+// Зависимости не проверяются, чтобы оркестратор не перезапускал сервер из-за недоступной базы.
+func HandleHealth(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w)
+}
+
+// HandleReady сообщает, готов ли сервер обслуживать запросы: база данных должна отвечать.
+func (h *Handler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	if err := h.Store.Ping(ctx); err != nil {
+		slog.WarnContext(r.Context(), "database is not reachable", "error", err)
+		writeError(w, r, newError(http.StatusServiceUnavailable, ErrCodeUnavailable, i18n.DatabaseUnavailable))
+		return
+	}
+	writeStatus(w)
+}
+
+// writeStatus отвечает {"status":"ok"}
+func writeStatus(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
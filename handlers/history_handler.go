@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_final_project/auth"
+	"go_final_project/constants"
+	"go_final_project/db"
+	"go_final_project/events"
+	"go_final_project/i18n"
+	"go_final_project/models"
+)
+
+// DefaultHistoryLimit лимит записей истории по умолчанию
+const DefaultHistoryLimit = 50
+
+// HistoryResponse структура ответа с историей выполнения задач
+type HistoryResponse struct {
+	Completions []models.Completion `json:"completions"`
+}
+
+// HandleHistory возвращает историю выполнения задач, начиная с последних.
+// Параметры from и to (YYYYMMDD, включительно) ограничивают дату выполнения,
+// id - задачу, limit - число записей.
+func (h *Handler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := db.CompletionFilter{Limit: DefaultHistoryLimit}
+	if limit := query.Get("limit"); limit != "" {
+		parsedLimit, err := strconv.Atoi(limit)
+		if err != nil || parsedLimit <= 0 {
+			writeError(w, r, invalidParam("limit", i18n.ParamInvalid, "limit"))
+			return
+		}
+		filter.Limit = parsedLimit
+	}
+	if from := query.Get("from"); from != "" {
+		date, err := time.ParseInLocation(constants.DateFormat, from, time.Local)
+		if err != nil {
+			writeError(w, r, invalidParam("from", i18n.ParamDateInvalid, "from"))
+			return
+		}
+		filter.From = date
+	}
+	if to := query.Get("to"); to != "" {
+		date, err := time.ParseInLocation(constants.DateFormat, to, time.Local)
+		if err != nil {
+			writeError(w, r, invalidParam("to", i18n.ParamDateInvalid, "to"))
+			return
+		}
+		filter.To = date.AddDate(0, 0, 1)
+	}
+	if query.Get("id") != "" {
+		taskID, apiErr := taskIDParam(r)
+		if apiErr != nil {
+			writeError(w, r, apiErr)
+			return
+		}
+		filter.TaskID = taskID
+	}
+
+	completions, err := h.Store.ListCompletions(r.Context(), auth.UserID(r.Context()), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list completions", "error", err)
+		writeError(w, r, internalError(i18n.HistoryListFailed))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(HistoryResponse{Completions: completions}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// HandleTaskUndo отменяет последнее выполнение задачи и возвращает её прежнее состояние
+func (h *Handler) HandleTaskUndo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	taskID, apiErr := taskIDParam(r)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
+		return
+	}
+
+	userID := auth.UserID(r.Context())
+	task, err := h.Store.UndoCompletion(r.Context(), userID, taskID)
+	switch {
+	case errors.Is(err, db.ErrNothingToUndo):
+		writeError(w, r, notFound(i18n.NothingToUndo))
+		return
+	case errors.Is(err, db.ErrTaskNotFound):
+		writeError(w, r, conflict(i18n.TaskDeletedAfter))
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "failed to undo completion", "task_id", taskID, "error", err)
+		writeError(w, r, internalError(i18n.UndoFailed))
+		return
+	}
+	h.publish(r.Context(), events.TaskUpdated, userID, *task)
+
+	describeRepeat(r.Context(), task)
+	if err := json.NewEncoder(w).Encode(task); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "task_id", taskID, "error", err)
+	}
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_final_project/auth"
+	"go_final_project/constants"
+	"go_final_project/db"
+	"go_final_project/events"
+	"go_final_project/i18n"
+	"go_final_project/ical"
+	"go_final_project/models"
+	"go_final_project/utils"
+)
+
+// maxImportSize максимальный размер загружаемого файла календаря
+const maxImportSize = 5 << 20
+
+// Статусы элементов в отчёте об импорте
+const (
+	importCreated = "created"
+	importSkipped = "skipped"
+	importFailed  = "error"
+)
+
+// ImportItem результат импорта одного события или задачи календаря
+type ImportItem struct {
+	UID    string `json:"uid,omitempty"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Date   string `json:"date,omitempty"`
+	Repeat string `json:"repeat,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportResponse отчёт об импорте календаря
+type ImportResponse struct {
+	Created int          `json:"created"`
+	Skipped int          `json:"skipped"`
+	Failed  int          `json:"failed"`
+	Items   []ImportItem `json:"items"`
+}
+
+// feedParam параметр адреса с токеном подписки на календарь
+const feedParam = "feed"
+
+// RequireFeedAuth пропускает запрос с действующим токеном подписки в параметре feed.
+// Календари не передают cookie и заголовки, а обычный токен живёт несколько часов,
+// поэтому для подписки выдаётся отдельный долгоживущий токен. Без параметра feed
+// запрос проверяется как обычно.
+func (h *Handler) RequireFeedAuth(next http.HandlerFunc) http.HandlerFunc {
+	withToken := h.RequireAuth(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		feed := r.URL.Query().Get(feedParam)
+		if feed == "" {
+			withToken(w, r)
+			return
+		}
+
+		userID, err := h.Store.FeedTokenUser(r.Context(), feed)
+		if errors.Is(err, db.ErrFeedTokenNotFound) {
+			slog.WarnContext(r.Context(), "feed token rejected")
+			writeError(w, r, unauthorized(i18n.AuthRequired))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to check feed token", "error", err)
+			writeError(w, r, internalError(i18n.TokenCheckFailed))
+			return
+		}
+
+		next(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
+	}
+}
+
+// HandleFeedToken управляет токеном подписки на календарь:
+// POST выдаёт новый токен (прежний перестаёт действовать), DELETE отзывает его
+func (h *Handler) HandleFeedToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	userID := auth.UserID(r.Context())
+	switch r.Method {
+	case http.MethodPost:
+		token, err := h.Store.ResetFeedToken(r.Context(), userID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to reset feed token", "error", err)
+			writeError(w, r, internalError(i18n.FeedTokenFailed))
+			return
+		}
+		slog.InfoContext(r.Context(), "feed token issued")
+		if err := json.NewEncoder(w).Encode(map[string]any{
+			"token": token,
+			"url":   "/api/calendar.ics?" + feedParam + "=" + token,
+		}); err != nil {
+			slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+		}
+	case http.MethodDelete:
+		if err := h.Store.RevokeFeedToken(r.Context(), userID); err != nil {
+			slog.ErrorContext(r.Context(), "failed to revoke feed token", "error", err)
+			writeError(w, r, internalError(i18n.FeedTokenFailed))
+			return
+		}
+		slog.InfoContext(r.Context(), "feed token revoked")
+		if err := json.NewEncoder(w).Encode(map[string]any{}); err != nil {
+			slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+		}
+	default:
+		writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// HandleCalendar отдаёт задачи пользователя в формате iCalendar для подписки из календарей
+func (h *Handler) HandleCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	tasks, err := h.Store.ListTasks(r.Context(), auth.UserID(r.Context()), db.TaskFilter{})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list tasks for calendar", "error", err)
+		writeError(w, r, internalError(i18n.TasksListFailed))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := ical.WriteCalendar(&buf, i18n.T(i18n.Lang(r.Context()), i18n.CalendarName), tasks, time.Now()); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write calendar", "error", err)
+		writeError(w, r, internalError(i18n.CalendarFailed))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+	w.Write(buf.Bytes())
+}
+
+// HandleCalendarImport создаёт задачи из загруженного файла .ics.
+// Файл передаётся телом запроса или полем file формы multipart/form-data.
+// Все подходящие задачи добавляются в одной транзакции, в ответе - отчёт по каждому элементу.
+func (h *Handler) HandleCalendarImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	body, err := calendarBody(r)
+	if err != nil {
+		slog.DebugContext(r.Context(), "failed to read calendar file", "error", err)
+		writeError(w, r, calendarError(err, i18n.CalendarReadFailed))
+		return
+	}
+	defer body.Close()
+
+	items, err := ical.Parse(body)
+	if err != nil {
+		slog.DebugContext(r.Context(), "invalid calendar", "error", err)
+		writeError(w, r, calendarError(err, i18n.CalendarInvalid))
+		return
+	}
+
+	now := utils.NormalizeDate(time.Now())
+	response := ImportResponse{Items: make([]ImportItem, 0, len(items))}
+	var tasks []models.Task
+	var created []int // индексы элементов отчёта для добавляемых задач
+	for _, item := range items {
+		task, result := importTask(i18n.Lang(r.Context()), item, now)
+		if result.Status == importCreated {
+			tasks = append(tasks, task)
+			created = append(created, len(response.Items))
+		}
+		response.Items = append(response.Items, result)
+	}
+
+	if len(tasks) > 0 {
+		userID := auth.UserID(r.Context())
+		ids, err := h.Store.AddTasks(r.Context(), userID, tasks)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to import tasks", "error", err)
+			writeError(w, r, internalError(i18n.TasksAddFailed))
+			return
+		}
+		for i, id := range ids {
+			response.Items[created[i]].ID = strconv.FormatInt(id, 10)
+			tasks[i].ID = strconv.FormatInt(id, 10)
+			h.publish(r.Context(), events.TaskCreated, userID, tasks[i])
+		}
+	}
+
+	for _, item := range response.Items {
+		switch item.Status {
+		case importCreated:
+			response.Created++
+		case importSkipped:
+			response.Skipped++
+		default:
+			response.Failed++
+		}
+	}
+	slog.InfoContext(r.Context(), "calendar imported",
+		"created", response.Created, "skipped", response.Skipped, "failed", response.Failed)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// calendarBody возвращает содержимое загруженного календаря
+func calendarBody(r *http.Request) (io.ReadCloser, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	file, _, err := r.FormFile("file")
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, errors.New("form field file is missing")
+	}
+	return file, err
+}
+
+// calendarError ответ на ошибку чтения или разбора загруженного календаря:
+// 413, если файл превысил maxImportSize, иначе 400 с сообщением key
+func calendarError(err error, key i18n.Key) *APIError {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return newError(http.StatusRequestEntityTooLarge, ErrCodeTooLarge, i18n.CalendarTooLarge, tooLarge.Limit>>20)
+	}
+	return badRequest(key)
+}
+
+// importTask переводит элемент календаря в задачу по тем же правилам, что и при добавлении:
+// прошедшая повторяющаяся задача переносится на ближайшую дату, а прошедшая
+// разовая пропускается. Ошибки в отчёте пишутся на языке lang.
+func importTask(lang string, item ical.Item, now time.Time) (models.Task, ImportItem) {
+	result := ImportItem{UID: item.UID, Title: item.Summary, Status: importFailed}
+	task := models.Task{Title: item.Summary, Comment: item.Description, Date: item.Start}
+
+	if item.Err != nil {
+		result.Error = i18n.T(lang, i18n.ImportDateInvalid, item.Err)
+		return task, result
+	}
+	if task.Title == "" {
+		result.Error = i18n.T(lang, i18n.TitleRequired)
+		return task, result
+	}
+
+	repeat, err := ical.Repeat(item.RRule, item.Start)
+	if err != nil {
+		result.Error = i18n.T(lang, i18n.ImportRRule, item.RRule)
+		return task, result
+	}
+	task.Repeat = repeat
+
+	date, _ := time.Parse(constants.DateFormat, task.Date)
+	if date.Before(now) {
+		if task.Repeat == "" {
+			result.Status = importSkipped
+			result.Error = i18n.T(lang, i18n.ImportPast)
+			return task, result
+		}
+		task.Date, err = utils.NextDate(now, task.Date, task.Repeat)
+		if err != nil {
+			result.Error = i18n.T(lang, i18n.ImportRepeat, task.Repeat)
+			return task, result
+		}
+	}
+
+	result.Status = importCreated
+	result.Date = task.Date
+	result.Repeat = task.Repeat
+	return task, result
+}
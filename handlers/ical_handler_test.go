@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedToken(t *testing.T) {
+	server := testServer(openTestStore(t), "secret")
+	_, ret := call(t, server, http.MethodPost, "/api/signin", "", map[string]any{"password": "secret"})
+	token, _ := ret["token"].(string)
+	require.NotEmpty(t, token)
+
+	status, _ := call(t, server, http.MethodPost, "/api/calendar/token", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, ret = call(t, server, http.MethodPost, "/api/calendar/token", token, nil)
+	require.Equal(t, http.StatusOK, status)
+	feed, _ := ret["token"].(string)
+	require.NotEmpty(t, feed)
+	assert.Equal(t, "/api/calendar.ics?feed="+feed, ret["url"])
+
+	calendar := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	rec := calendar("/api/calendar.ics?feed=" + feed)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/calendar")
+
+	// Обычный токен в адресе не принимается
+	assert.Equal(t, http.StatusUnauthorized, calendar("/api/calendar.ics?token="+token).Code)
+	assert.Equal(t, http.StatusUnauthorized, calendar("/api/calendar.ics?feed="+token).Code)
+
+	// Новый токен отзывает прежний
+	_, ret = call(t, server, http.MethodPost, "/api/calendar/token", token, nil)
+	renewed, _ := ret["token"].(string)
+	require.NotEmpty(t, renewed)
+	assert.NotEqual(t, feed, renewed)
+	assert.Equal(t, http.StatusUnauthorized, calendar("/api/calendar.ics?feed="+feed).Code)
+	assert.Equal(t, http.StatusOK, calendar("/api/calendar.ics?feed="+renewed).Code)
+
+	status, _ = call(t, server, http.MethodDelete, "/api/calendar/token", token, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, http.StatusUnauthorized, calendar("/api/calendar.ics?feed="+renewed).Code)
+}
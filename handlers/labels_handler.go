@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go_final_project/auth"
+	"go_final_project/i18n"
+	"go_final_project/models"
+)
+
+const (
+	maxTags        = 20 // максимальное число меток у задачи
+	maxLabelLength = 64 // максимальная длина названия метки или проекта
+)
+
+// normalizeLabels убирает пробелы и повторы в проекте и метках задачи и проверяет их.
+// Запятая запрещена, потому что в фильтре /api/tasks метки перечисляются через неё.
+func normalizeLabels(task *models.Task) *APIError {
+	task.Project = strings.TrimSpace(task.Project)
+	if apiErr := checkLabel("project", task.Project); apiErr != nil {
+		return apiErr
+	}
+
+	var tags []string
+	for _, tag := range task.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return invalidField("tags", i18n.TagEmpty)
+		}
+		if apiErr := checkLabel("tags", tag); apiErr != nil {
+			return apiErr
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxTags {
+		return invalidField("tags", i18n.TooManyTags)
+	}
+	task.Tags = tags
+	return nil
+}
+
+// checkLabel проверяет название метки или проекта из поля field
+func checkLabel(field, name string) *APIError {
+	if strings.Contains(name, ",") {
+		return invalidField(field, i18n.LabelComma)
+	}
+	if utf8.RuneCountInString(name) > maxLabelLength {
+		return invalidField(field, i18n.LabelTooLong)
+	}
+	return nil
+}
+
+// queryTags возвращает метки из параметров tag, которые можно повторять
+// или перечислять через запятую
+func queryTags(r *http.Request) []string {
+	var tags []string
+	for _, value := range r.URL.Query()["tag"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// HandleTags возвращает метки пользователя
+func (h *Handler) HandleTags(w http.ResponseWriter, r *http.Request) {
+	h.handleLabels(w, r, "tags", h.Store.ListTags)
+}
+
+// HandleProjects возвращает проекты пользователя
+func (h *Handler) HandleProjects(w http.ResponseWriter, r *http.Request) {
+	h.handleLabels(w, r, "projects", h.Store.ListProjects)
+}
+
+func (h *Handler) handleLabels(w http.ResponseWriter, r *http.Request, key string,
+	list func(ctx context.Context, userID int64) ([]string, error)) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	names, err := list(r.Context(), auth.UserID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list labels", "kind", key, "error", err)
+		writeError(w, r, internalError(i18n.LabelsListFailed))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]any{key: names}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
@@ -1,21 +1,31 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"go_final_project/auth"
 	"go_final_project/constants"
 	"go_final_project/db"
+	"go_final_project/events"
+	"go_final_project/i18n"
 	"go_final_project/models"
 	"go_final_project/utils"
 )
 
+const (
+	maxPriority = 3       // высший приоритет задачи
+	timeFormat  = "15:04" // формат времени задачи
+)
+
 // HandleTask обрабатывает запросы API для задач
 func (h *Handler) HandleTask(w http.ResponseWriter, r *http.Request) {
-	log.Printf("[INFO] Обработка запроса: %s %s", r.Method, r.URL.Path)
 	switch r.Method {
 	case http.MethodPost:
 		h.addTask(w, r)
@@ -26,21 +36,37 @@ func (h *Handler) HandleTask(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete:
 		h.deleteTask(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		log.Printf("[WARN] Метод %s не поддерживается", r.Method)
+		writeError(w, r, errMethodNotAllowed)
 	}
 }
 
 // addTask добавляет задачу
 func (h *Handler) addTask(w http.ResponseWriter, r *http.Request) {
-	log.Println("[INFO] Добавление новой задачи")
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
 	var task models.Task
 	err := json.NewDecoder(r.Body).Decode(&task)
 	if err != nil {
-		log.Printf("[ERROR] Неверный формат JSON, ошибка: %v", err)
-		writeError(w, "Неверный формат JSON")
+		slog.DebugContext(r.Context(), "invalid JSON body", "error", err)
+		writeError(w, r, badRequest(i18n.InvalidJSON))
+		return
+	}
+
+	if task.Repeat != "" {
+		if _, err := utils.ParseRepeat(task.Repeat); err != nil {
+			slog.DebugContext(r.Context(), "invalid repeat rule", "repeat", task.Repeat, "error", err)
+			writeError(w, r, invalidField("repeat", i18n.RepeatInvalid))
+			return
+		}
+	}
+
+	if apiErr := normalizeLabels(&task); apiErr != nil {
+		writeError(w, r, apiErr)
+		return
+	}
+
+	if apiErr := checkPriorityAndTime(task); apiErr != nil {
+		writeError(w, r, apiErr)
 		return
 	}
 
@@ -51,19 +77,20 @@ func (h *Handler) addTask(w http.ResponseWriter, r *http.Request) {
 	} else {
 		parsedDate, err := time.Parse(constants.DateFormat, task.Date)
 		if err != nil {
-			log.Printf("[ERROR] Неверный формат даты: %s, ошибка: %v", task.Date, err)
-			writeError(w, "Неверный формат даты (ожидается YYYYMMDD)")
+			writeError(w, r, invalidField("date", i18n.DateInvalid))
 			return
 		}
 
-		if parsedDate.Before(now) || parsedDate.Equal(now) {
+		// Задача на сегодня остаётся на сегодня, даже если она повторяется:
+		// на следующую дату переносятся только задачи из прошлого
+		if parsedDate.Before(now) {
 			if task.Repeat == "" {
 				task.Date = now.Format(constants.DateFormat)
 			} else {
 				task.Date, err = utils.NextDate(now, task.Date, task.Repeat)
 				if err != nil {
-					log.Printf("[ERROR] Некорректное правило повторения: %s, ошибка: %v", task.Repeat, err)
-					writeError(w, "Некорректное правило повторения")
+					slog.DebugContext(r.Context(), "invalid repeat rule", "repeat", task.Repeat, "error", err)
+					writeError(w, r, invalidField("repeat", i18n.RepeatInvalid))
 					return
 				}
 			}
@@ -71,207 +98,333 @@ func (h *Handler) addTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if task.Title == "" {
-		log.Println("[ERROR] Не указан заголовок задачи")
-		writeError(w, "Не указан заголовок задачи")
+		writeError(w, r, invalidField("title", i18n.TitleRequired))
 		return
 	}
 
-	id, err := db.AddTask(h.DB, task.Date, task.Title, task.Comment, task.Repeat)
+	userID := auth.UserID(r.Context())
+	id, err := h.Store.AddTask(r.Context(), userID, task)
 	if err != nil {
-		log.Printf("[ERROR] Ошибка при добавлении задачи, заголовок: %s, ошибка: %v", task.Title, err)
-		writeError(w, "Не удалось добавить задачу")
+		slog.ErrorContext(r.Context(), "failed to add task", "error", err)
+		writeError(w, r, internalError(i18n.TaskAddFailed))
 		return
 	}
-	log.Printf("[INFO] Задача добавлена с ID %d", id)
+	slog.InfoContext(r.Context(), "task added", "task_id", id)
+	task.ID = strconv.FormatInt(id, 10)
+	h.publish(r.Context(), events.TaskCreated, userID, task)
 	response := map[string]any{"id": strconv.FormatInt(id, 10)}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("[ERROR] Ошибка при формировании ответа, ID: %d, ошибка: %v", id, err)
-		writeError(w, "Ошибка при формировании ответа")
+		slog.ErrorContext(r.Context(), "failed to write response", "task_id", id, "error", err)
 	}
 }
 
 // getTask возвращает данные задачи по идентификатору
 func (h *Handler) getTask(w http.ResponseWriter, r *http.Request) {
-	log.Println("[INFO] Получение задачи")
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		log.Println("[ERROR] Не указан идентификатор задачи")
-		writeError(w, "Не указан идентификатор задачи")
-		return
-	}
-
-	taskID, err := strconv.Atoi(id)
-	if err != nil {
-		log.Printf("[ERROR] Неверный формат идентификатора задачи: %s", id)
-		writeError(w, "Идентификатор задачи должен быть числом")
+	taskID, apiErr := taskIDParam(r)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
 		return
 	}
 
-	task, err := db.GetTaskByID(h.DB, taskID)
+	task, err := h.Store.GetTaskByID(r.Context(), auth.UserID(r.Context()), taskID)
 	if err != nil {
-		log.Printf("[ERROR] Ошибка при получении задачи, ID: %d, ошибка: %v", taskID, err)
-		writeError(w, "Ошибка при получении задачи")
+		writeError(w, r, taskError(r, taskID, err, i18n.TaskGetFailed))
 		return
 	}
 
+	describeRepeat(r.Context(), task)
+	w.Header().Set("ETag", taskETag(task.Version))
 	if err := json.NewEncoder(w).Encode(task); err != nil {
-		log.Printf("[ERROR] Ошибка при формировании ответа, ID: %d, ошибка: %v", taskID, err)
-		writeError(w, "Ошибка при формировании ответа")
+		slog.ErrorContext(r.Context(), "failed to write response", "task_id", taskID, "error", err)
 	}
 }
 
 // editTask обновляет параметры задачи
 func (h *Handler) editTask(w http.ResponseWriter, r *http.Request) {
-	log.Println("[INFO] Обновление задачи")
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	var task models.Task
-	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		log.Printf("[ERROR] Неверный формат JSON, ошибка: %v", err)
-		writeError(w, "Неверный формат JSON")
+	// Необязательные поля разбираются отдельно, чтобы отличить отсутствующее поле от пустого
+	var req struct {
+		models.Task
+		Priority *int      `json:"priority"`
+		Time     *string   `json:"time"`
+		Project  *string   `json:"project"`
+		Tags     *[]string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.DebugContext(r.Context(), "invalid JSON body", "error", err)
+		writeError(w, r, badRequest(i18n.InvalidJSON))
 		return
 	}
+	task := req.Task
+
+	// Версия из If-Match важнее версии в теле запроса
+	version, conditional, apiErr := ifMatchVersion(r)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
+		return
+	}
+	if conditional {
+		task.Version = version
+	}
 
 	if task.ID == "" {
-		log.Println("[ERROR] Не указан идентификатор задачи")
-		writeError(w, "Не указан идентификатор задачи")
+		writeError(w, r, invalidField("id", i18n.TaskIDRequired))
+		return
+	}
+	taskID, err := strconv.ParseInt(task.ID, 10, 64)
+	if err != nil {
+		writeError(w, r, invalidField("id", i18n.TaskIDInvalid))
 		return
 	}
 
 	if task.Date != "" {
 		if _, err := time.Parse(constants.DateFormat, task.Date); err != nil {
-			log.Printf("[ERROR] Неверный формат даты: %s, ошибка: %v", task.Date, err)
-			writeError(w, "Неверный формат даты (ожидается YYYYMMDD)")
+			writeError(w, r, invalidField("date", i18n.DateInvalid))
 			return
 		}
 	} else {
 		task.Date = utils.NormalizeDate(time.Now()).Format(constants.DateFormat)
 	}
 
+	if task.Repeat != "" {
+		if _, err := utils.ParseRepeat(task.Repeat); err != nil {
+			slog.DebugContext(r.Context(), "invalid repeat rule", "repeat", task.Repeat, "error", err)
+			writeError(w, r, invalidField("repeat", i18n.RepeatInvalid))
+			return
+		}
+	}
+
 	if task.Title == "" {
-		log.Println("[ERROR] Заголовок задачи обязателен")
-		writeError(w, "Заголовок задачи обязателен")
+		writeError(w, r, invalidField("title", i18n.TitleRequired))
 		return
 	}
 
-	rowsAffected, err := db.UpdateTask(h.DB, task)
-	if err != nil || rowsAffected == 0 {
-		log.Printf("[ERROR] Ошибка при обновлении задачи, ID: %s, ошибка: %v", task.ID, err)
-		writeError(w, "Задача не найдена или не удалось обновить")
+	userID := auth.UserID(r.Context())
+	current, err := h.Store.GetTaskByID(r.Context(), userID, taskID)
+	if err != nil {
+		writeError(w, r, taskError(r, taskID, err, i18n.TaskUpdateFailed))
 		return
 	}
+	// Запрос без версии изменяет прочитанную версию: запись не затрёт изменение,
+	// сделанное между чтением и записью, и новая версия задачи известна
+	if task.Version == 0 {
+		task.Version = current.Version
+	}
+
+	// Клиенты, которые не знают о необязательных полях, не должны их стирать
+	task.Priority, task.Time = current.Priority, current.Time
+	task.Project, task.Tags = current.Project, current.Tags
+	if req.Priority != nil {
+		task.Priority = *req.Priority
+	}
+	if req.Time != nil {
+		task.Time = *req.Time
+	}
+	if req.Project != nil {
+		task.Project = *req.Project
+	}
+	if req.Tags != nil {
+		task.Tags = *req.Tags
+	}
+	if apiErr := normalizeLabels(&task); apiErr != nil {
+		writeError(w, r, apiErr)
+		return
+	}
+	if apiErr := checkPriorityAndTime(task); apiErr != nil {
+		writeError(w, r, apiErr)
+		return
+	}
+
+	rowsAffected, err := h.Store.UpdateTask(r.Context(), userID, task)
+	if errors.Is(err, db.ErrVersionConflict) {
+		writeError(w, r, versionError(conditional))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to update task", "task_id", task.ID, "error", err)
+		writeError(w, r, internalError(i18n.TaskUpdateFailed))
+		return
+	}
+	if rowsAffected == 0 {
+		writeError(w, r, notFound(i18n.TaskNotFound))
+		return
+	}
+	// Задача обновлена только при совпадении версии, поэтому новая версия известна
+	task.Version++
+	w.Header().Set("ETag", taskETag(task.Version))
+	h.publish(r.Context(), events.TaskUpdated, userID, task)
 
 	if err := json.NewEncoder(w).Encode(map[string]any{}); err != nil {
-		log.Printf("[ERROR] Ошибка при отправке ответа, ID: %s, ошибка: %v", task.ID, err)
-		writeError(w, "Ошибка при отправке ответа")
+		slog.ErrorContext(r.Context(), "failed to write response", "task_id", task.ID, "error", err)
 	}
 }
 
 // HandleTaskDone завершает задачу
 func (h *Handler) HandleTaskDone(w http.ResponseWriter, r *http.Request) {
-	log.Println("[INFO] Завершение задачи")
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		log.Println("[ERROR] Не указан идентификатор задачи")
-		writeError(w, "Не указан идентификатор задачи")
+	taskID, apiErr := taskIDParam(r)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
 		return
 	}
 
-	taskID, err := strconv.Atoi(id)
-	if err != nil {
-		log.Printf("[ERROR] Неверный формат идентификатора задачи: %s", id)
-		writeError(w, "Идентификатор задачи должен быть числом")
+	version, conditional, apiErr := ifMatchVersion(r)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
 		return
 	}
 
 	// Получаем задачу из базы данных
-	task, err := db.GetTaskByID(h.DB, taskID)
+	userID := auth.UserID(r.Context())
+	task, err := h.Store.GetTaskByID(r.Context(), userID, taskID)
 	if err != nil {
-		log.Printf("[ERROR] Ошибка при получении задачи, ID: %d, ошибка: %v", taskID, err)
-		writeError(w, "Ошибка при получении задачи")
+		writeError(w, r, taskError(r, taskID, err, i18n.TaskGetFailed))
+		return
+	}
+	if conditional && version != 0 && version != task.Version {
+		writeError(w, r, versionError(true))
 		return
 	}
 
-	if task.Repeat == "" {
-		// Если задача одноразовая, удаляем её
-		_, err = db.DeleteTask(h.DB, taskID)
-		if err != nil {
-			log.Printf("[ERROR] Не удалось удалить задачу, ID: %d, ошибка: %v", taskID, err)
-			writeError(w, "Не удалось удалить задачу")
-			return
-		}
-	} else {
-		// Если задача повторяющаяся, обновляем дату
+	// Разовая задача удаляется, повторяющаяся переносится на следующую дату.
+	// В обоих случаях выполнение сохраняется в истории.
+	var nextDate string
+	if task.Repeat != "" {
 		now := utils.NormalizeDate(time.Now())
-		nextDate, err := utils.NextDate(now, task.Date, task.Repeat)
+		nextDate, err = utils.NextDate(now, task.Date, task.Repeat)
 		if err != nil {
-			log.Printf("[ERROR] Ошибка при расчёте следующей даты, ID: %d, ошибка: %v", taskID, err)
-			writeError(w, "Ошибка при расчёте следующей даты")
+			slog.ErrorContext(r.Context(), "failed to calculate next date", "task_id", taskID, "error", err)
+			writeError(w, r, internalError(i18n.NextDateFailed))
 			return
 		}
+	}
 
-		task.Date = nextDate
-		_, err = db.UpdateTask(h.DB, *task)
-		if err != nil {
-			log.Printf("[ERROR] Не удалось обновить задачу, ID: %d, ошибка: %v", taskID, err)
-			writeError(w, "Не удалось обновить задачу")
-			return
-		}
+	// Версия прочитанной задачи защищает от изменений, сделанных после чтения
+	_, err = h.Store.CompleteTask(r.Context(), userID, *task, nextDate)
+	if errors.Is(err, db.ErrVersionConflict) {
+		writeError(w, r, versionError(conditional))
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to complete task", "task_id", taskID, "error", err)
+		writeError(w, r, internalError(i18n.TaskDoneFailed))
+		return
 	}
+	// Повторяющаяся задача остаётся с новой датой и следующей версией
+	if nextDate != "" {
+		w.Header().Set("ETag", taskETag(task.Version+1))
+	}
+	event := events.New(events.TaskDone, userID, *task)
+	event.NextDate = nextDate
+	h.publishEvent(r.Context(), event)
 
 	if err := json.NewEncoder(w).Encode(map[string]any{}); err != nil {
-		log.Printf("[ERROR] Ошибка при отправке ответа, ID: %d, ошибка: %v", taskID, err)
-		writeError(w, "Ошибка при отправке ответа")
+		slog.ErrorContext(r.Context(), "failed to write response", "task_id", taskID, "error", err)
 	}
 }
 
 // deleteTask удаляет задачу по идентификатору
 func (h *Handler) deleteTask(w http.ResponseWriter, r *http.Request) {
-	log.Println("[INFO] Удаление задачи")
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
-	id := r.URL.Query().Get("id")
-	if id == "" {
-		log.Println("[ERROR] Не указан идентификатор задачи")
-		writeError(w, "Не указан идентификатор задачи")
+	taskID, apiErr := taskIDParam(r)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
 		return
 	}
 
-	taskID, err := strconv.Atoi(id)
-	if err != nil {
-		log.Printf("[ERROR] Неверный формат идентификатора задачи: %s", id)
-		writeError(w, "Идентификатор задачи должен быть числом")
+	// Задача нужна для события, после удаления она доступна только в корзине
+	userID := auth.UserID(r.Context())
+	task, err := h.Store.GetTaskByID(r.Context(), userID, taskID)
+	if err != nil && !errors.Is(err, db.ErrTaskNotFound) {
+		slog.ErrorContext(r.Context(), "failed to get task", "task_id", taskID, "error", err)
+		writeError(w, r, internalError(i18n.TaskDeleteFailed))
 		return
 	}
 
-	// Удаляем задачу из базы данных через db.DeleteTask
-	rowsAffected, err := db.DeleteTask(h.DB, taskID)
+	// Перемещаем задачу в корзину, откуда её можно восстановить
+	rowsAffected, err := h.Store.DeleteTask(r.Context(), userID, taskID)
 	if err != nil {
-		log.Printf("[ERROR] Ошибка при удалении задачи, ID: %d, ошибка: %v", taskID, err)
-		writeError(w, "Не удалось удалить задачу")
+		slog.ErrorContext(r.Context(), "failed to delete task", "task_id", taskID, "error", err)
+		writeError(w, r, internalError(i18n.TaskDeleteFailed))
 		return
 	}
 
 	// Проверяем, была ли удалена задача
 	if rowsAffected == 0 {
-		log.Printf("[WARNING] Попытка удалить несуществующую задачу, ID: %d", taskID)
-		writeError(w, "Задача не найдена")
+		writeError(w, r, notFound(i18n.TaskNotFound))
 		return
 	}
+	if task != nil {
+		h.publish(r.Context(), events.TaskDeleted, userID, *task)
+	}
 
 	if err := json.NewEncoder(w).Encode(map[string]any{}); err != nil {
-		log.Printf("[ERROR] Ошибка при отправке ответа, ID: %d, ошибка: %v", taskID, err)
-		writeError(w, "Ошибка при отправке ответа")
+		slog.ErrorContext(r.Context(), "failed to write response", "task_id", taskID, "error", err)
+	}
+}
+
+// checkPriorityAndTime проверяет приоритет (0-3) и время задачи (HH:MM)
+func checkPriorityAndTime(task models.Task) *APIError {
+	if task.Priority < 0 || task.Priority > maxPriority {
+		return invalidField("priority", i18n.PriorityInvalid, maxPriority)
+	}
+	if task.Time != "" {
+		parsed, err := time.Parse(timeFormat, task.Time)
+		if err != nil || parsed.Format(timeFormat) != task.Time {
+			return invalidField("time", i18n.TimeInvalid)
+		}
+	}
+	return nil
+}
+
+// taskError переводит ошибку чтения задачи taskID в ответ: 404, если задачи нет,
+// иначе 500 с сообщением key и записью причины в журнал.
+func taskError(r *http.Request, taskID int64, err error, key i18n.Key) *APIError {
+	if errors.Is(err, db.ErrTaskNotFound) {
+		return notFound(i18n.TaskNotFound)
 	}
+	slog.ErrorContext(r.Context(), "failed to get task", "task_id", taskID, "error", err)
+	return internalError(key)
 }
 
-// writeError отправляет сообщение об ошибке в формате JSON
-func writeError(w http.ResponseWriter, message string) {
-	log.Printf("[ERROR] %s", message)
-	w.WriteHeader(http.StatusBadRequest)
-	json.NewEncoder(w).Encode(map[string]any{"error": message})
+// describeRepeat заполняет описание правила повторения задачи на языке запроса
+func describeRepeat(ctx context.Context, task *models.Task) {
+	task.RepeatText = i18n.DescribeRepeat(i18n.Lang(ctx), task.Repeat)
+}
+
+// taskETag возвращает ETag задачи с версией version
+func taskETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion возвращает версию задачи из заголовка If-Match и признак того,
+// что заголовок указан. Для "*" возвращается версия 0: подходит любая версия.
+func ifMatchVersion(r *http.Request) (int64, bool, *APIError) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, false, nil
+	}
+	if value == "*" {
+		return 0, true, nil
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false, invalidParam("If-Match", i18n.ParamInvalid, "If-Match")
+	}
+	return version, true, nil
+}
+
+// versionError ответ на запись устаревшей версии задачи: 412, если версия
+// указана в If-Match, и 409, если в теле запроса
+func versionError(conditional bool) *APIError {
+	if conditional {
+		return preconditionFailed(i18n.TaskModified)
+	}
+	return conflict(i18n.TaskModified)
 }
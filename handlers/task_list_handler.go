@@ -1,20 +1,32 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"go_final_project/auth"
+	"go_final_project/constants"
+	"go_final_project/db"
+	"go_final_project/i18n"
 	"go_final_project/models"
 )
 
 // Константа для лимита задач
 const DefaultTaskLimit = 50
 
+// searchDateFormat формат даты в строке поиска (DD.MM.YYYY)
+const searchDateFormat = "02.01.2006"
+
 // TaskListResponse структура ответа со списком задач
 type TaskListResponse struct {
-	Tasks []models.Task `json:"tasks"`
+	Tasks      []models.Task `json:"tasks"`
+	NextCursor string        `json:"next_cursor,omitempty"` // курсор следующей страницы, пустой на последней
 }
 
 // HandleTaskList обрабатывает GET-запросы для получения списка задач
@@ -29,44 +41,38 @@ func (h *Handler) HandleTaskList(w http.ResponseWriter, r *http.Request) {
 		if parsedLimit, err := strconv.Atoi(queryLimit); err == nil && parsedLimit > 0 {
 			limit = parsedLimit
 		} else {
-			log.Printf("[ОШИБКА] Неверный параметр 'limit': %s", queryLimit)
-			writeError(w, "Неверный параметр 'limit'")
+			writeError(w, r, invalidParam("limit", i18n.ParamInvalid, "limit"))
 			return
 		}
 	}
 
-	// Выполняем запрос к базе данных
-	rows, err := h.DB.Query(
-		"SELECT id, date, title, comment, repeat FROM scheduler ORDER BY date LIMIT ?",
-		limit,
-	)
-	if err != nil {
-		log.Printf("[ОШИБКА] Не удалось выполнить запрос к базе данных: %v", err)
-		writeError(w, "Failed to retrieve tasks")
-		return
+	// Выбираем задачи: по дате, по строке поиска или все подряд.
+	// Одна лишняя задача показывает, есть ли следующая страница
+	filter := db.TaskFilter{Limit: limit + 1}
+	search := strings.TrimSpace(r.URL.Query().Get("search"))
+	if date, err := time.Parse(searchDateFormat, search); err == nil {
+		filter.Date = date.Format(constants.DateFormat)
+	} else {
+		filter.Search = search
 	}
-	defer rows.Close()
-
-	// Читаем данные из результата запроса
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var id int64 // SQLite возвращает id в виде INTEGER
-		err := rows.Scan(&id, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+	// Проект и метки (все указанные) сужают любую из выборок
+	filter.Project = strings.TrimSpace(r.URL.Query().Get("project"))
+	filter.Tags = queryTags(r)
+
+	// Страница начинается после задачи, на которой закончилась предыдущая
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		after, err := decodeCursor(cursor)
 		if err != nil {
-			log.Printf("[ОШИБКА] Не удалось разобрать задачу: %v", err)
-			writeError(w, "Failed to parse tasks")
+			writeError(w, r, invalidParam("cursor", i18n.ParamInvalid, "cursor"))
 			return
 		}
-		// Преобразуем id в строку
-		task.ID = strconv.FormatInt(id, 10)
-		tasks = append(tasks, task)
+		filter.After = after
 	}
 
-	// Обрабатываем ошибку после завершения итерации
-	if err := rows.Err(); err != nil {
-		log.Printf("[ОШИБКА] Ошибка при итерации по строкам: %v", err)
-		writeError(w, "Error iterating over rows")
+	tasks, err := h.Store.ListTasks(r.Context(), auth.UserID(r.Context()), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list tasks", "error", err)
+		writeError(w, r, internalError(i18n.TasksListFailed))
 		return
 	}
 
@@ -75,10 +81,48 @@ func (h *Handler) HandleTaskList(w http.ResponseWriter, r *http.Request) {
 		tasks = []models.Task{}
 	}
 
+	for i := range tasks {
+		describeRepeat(r.Context(), &tasks[i])
+	}
+
 	// Формируем и отправляем JSON-ответ
 	response := TaskListResponse{Tasks: tasks}
+	if len(tasks) > limit {
+		response.Tasks = tasks[:limit]
+		response.NextCursor = encodeCursor(tasks[limit-1])
+	}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("[ОШИБКА] Не удалось закодировать задачи в JSON: %v", err)
-		writeError(w, "Failed to encode tasks")
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// taskCursor содержимое курсора страницы
+type taskCursor struct {
+	Date     string `json:"d"`
+	Time     string `json:"t,omitempty"`
+	Priority int    `json:"p,omitempty"`
+	ID       int64  `json:"i"`
+}
+
+// encodeCursor возвращает курсор, указывающий на позицию задачи в списке
+func encodeCursor(task models.Task) string {
+	id, _ := strconv.ParseInt(task.ID, 10, 64)
+	data, _ := json.Marshal(taskCursor{Date: task.Date, Time: task.Time, Priority: task.Priority, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор, полученный от encodeCursor
+func decodeCursor(cursor string) (*db.TaskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var c taskCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse(constants.DateFormat, c.Date); err != nil || c.ID <= 0 {
+		return nil, errors.New("invalid cursor")
 	}
+	return &db.TaskCursor{Date: c.Date, Time: c.Time, Priority: c.Priority, ID: c.ID}, nil
 }
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"go_final_project/auth"
+	"go_final_project/events"
+	"go_final_project/i18n"
+)
+
+// HandleTrash возвращает задачи из корзины
+func (h *Handler) HandleTrash(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	tasks, err := h.Store.ListTrash(r.Context(), auth.UserID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list trash", "error", err)
+		writeError(w, r, internalError(i18n.TrashListFailed))
+		return
+	}
+
+	for i := range tasks {
+		describeRepeat(r.Context(), &tasks[i])
+	}
+
+	if err := json.NewEncoder(w).Encode(TaskListResponse{Tasks: tasks}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// HandleTrashRestore возвращает задачу из корзины
+func (h *Handler) HandleTrashRestore(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	taskID, apiErr := taskIDParam(r)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
+		return
+	}
+
+	userID := auth.UserID(r.Context())
+	rowsAffected, err := h.Store.RestoreTask(r.Context(), userID, taskID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to restore task", "task_id", taskID, "error", err)
+		writeError(w, r, internalError(i18n.TaskRestoreFailed))
+		return
+	}
+	if rowsAffected == 0 {
+		writeError(w, r, notFound(i18n.TaskNotInTrash))
+		return
+	}
+	if task, err := h.Store.GetTaskByID(r.Context(), userID, taskID); err == nil {
+		h.publish(r.Context(), events.TaskUpdated, userID, *task)
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]any{}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "task_id", taskID, "error", err)
+	}
+}
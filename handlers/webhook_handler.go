@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"go_final_project/auth"
+	"go_final_project/events"
+	"go_final_project/i18n"
+	"go_final_project/models"
+	"go_final_project/webhooks"
+)
+
+// WebhookListResponse структура ответа со списком подписок
+type WebhookListResponse struct {
+	Webhooks []models.Webhook `json:"webhooks"`
+}
+
+// DeliveryListResponse структура ответа со списком недоставленных событий
+type DeliveryListResponse struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
+
+// HandleWebhooks обрабатывает запросы API для подписок на события
+func (h *Handler) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listWebhooks(w, r)
+	case http.MethodPost:
+		h.addWebhook(w, r)
+	case http.MethodDelete:
+		h.deleteWebhook(w, r)
+	default:
+		writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// listWebhooks возвращает подписки пользователя
+func (h *Handler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.Store.ListWebhooks(r.Context(), auth.UserID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list webhooks", "error", err)
+		writeError(w, r, internalError(i18n.WebhooksListFailed))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(WebhookListResponse{Webhooks: webhooks}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// addWebhook создаёт подписку и возвращает её ID и ключ подписи.
+// Ключ возвращается только в ответе на создание.
+func (h *Handler) addWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		slog.DebugContext(r.Context(), "invalid JSON body", "error", err)
+		writeError(w, r, badRequest(i18n.InvalidJSON))
+		return
+	}
+
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		writeError(w, r, invalidField("url", i18n.WebhookURLInvalid))
+		return
+	}
+	if err := h.webhookGuard().CheckHost(r.Context(), parsed.Hostname()); err != nil {
+		slog.WarnContext(r.Context(), "webhook host rejected", "host", parsed.Hostname(), "error", err)
+		if errors.Is(err, webhooks.ErrPrivateAddress) {
+			writeError(w, r, invalidField("url", i18n.WebhookHostPrivate))
+		} else {
+			writeError(w, r, invalidField("url", i18n.WebhookHostUnknown, parsed.Hostname()))
+		}
+		return
+	}
+
+	var eventTypes []string
+	for _, eventType := range webhook.Events {
+		if !slices.Contains(events.Types, eventType) {
+			writeError(w, r, invalidField("events", i18n.WebhookEventUnknown, eventType))
+			return
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	webhook.Events = eventTypes
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		slog.ErrorContext(r.Context(), "failed to generate webhook secret", "error", err)
+		writeError(w, r, internalError(i18n.WebhookAddFailed))
+		return
+	}
+	webhook.Secret = hex.EncodeToString(buf)
+	webhook.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	id, err := h.Store.AddWebhook(r.Context(), auth.UserID(r.Context()), webhook)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to add webhook", "error", err)
+		writeError(w, r, internalError(i18n.WebhookAddFailed))
+		return
+	}
+	slog.InfoContext(r.Context(), "webhook added", "webhook_id", id)
+	webhook.ID = strconv.FormatInt(id, 10)
+
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "id", id, "error", err)
+	}
+}
+
+// webhookGuard возвращает проверку адресов подписок; без диспетчера
+// запрещены все внутренние адреса
+func (h *Handler) webhookGuard() webhooks.Guard {
+	if h.Webhooks == nil {
+		return webhooks.Guard{}
+	}
+	return h.Webhooks.Guard
+}
+
+// deleteWebhook удаляет подписку вместе с очередью её доставок
+func (h *Handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, apiErr := idParam(r, "id", i18n.WebhookIDRequired, i18n.WebhookIDInvalid)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
+		return
+	}
+
+	rowsAffected, err := h.Store.DeleteWebhook(r.Context(), auth.UserID(r.Context()), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to delete webhook", "webhook_id", id, "error", err)
+		writeError(w, r, internalError(i18n.WebhookDeleteFailed))
+		return
+	}
+	if rowsAffected == 0 {
+		writeError(w, r, notFound(i18n.WebhookNotFound))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]any{}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "id", id, "error", err)
+	}
+}
+
+// HandleDeadDeliveries возвращает события, которые не удалось доставить после всех попыток
+func (h *Handler) HandleDeadDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	deliveries, err := h.Store.ListDeadDeliveries(r.Context(), auth.UserID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to list dead deliveries", "error", err)
+		writeError(w, r, internalError(i18n.DeliveriesListFailed))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(DeliveryListResponse{Deliveries: deliveries}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "error", err)
+	}
+}
+
+// HandleDeliveryRetry возвращает недоставленное событие в очередь доставки
+func (h *Handler) HandleDeliveryRetry(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, errMethodNotAllowed)
+		return
+	}
+
+	id, apiErr := idParam(r, "id", i18n.DeliveryIDRequired, i18n.DeliveryIDInvalid)
+	if apiErr != nil {
+		writeError(w, r, apiErr)
+		return
+	}
+
+	rowsAffected, err := h.Store.RetryDelivery(r.Context(), auth.UserID(r.Context()), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to retry delivery", "delivery_id", id, "error", err)
+		writeError(w, r, internalError(i18n.DeliveryRetryFailed))
+		return
+	}
+	if rowsAffected == 0 {
+		writeError(w, r, notFound(i18n.DeliveryNotFound))
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]any{}); err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "id", id, "error", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package i18n переводит сообщения API и описания правил повторения на язык пользователя.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Поддерживаемые языки
+const (
+	RU = "ru"
+	EN = "en"
+)
+
+// Languages поддерживаемые языки, первый используется по умолчанию
+var Languages = []string{RU, EN}
+
+// LangCookie cookie, в которой интерфейс хранит выбранный пользователем язык.
+// Она важнее заголовка Accept-Language, который задаёт браузер.
+const LangCookie = "lang"
+
+// Key идентификатор сообщения в каталоге
+type Key string
+
+// T возвращает сообщение key на языке lang, подставляя args как в fmt.Sprintf.
+// Если перевода нет, используется русский текст, если нет и его - сам ключ.
+func T(lang string, key Key, args ...any) string {
+	texts, ok := messages[key]
+	if !ok {
+		return string(key)
+	}
+	text, ok := texts[lang]
+	if !ok {
+		text = texts[RU]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Supported проверяет, что язык lang поддерживается
+func Supported(lang string) bool {
+	return slices.Contains(Languages, lang)
+}
+
+// Match выбирает поддерживаемый язык по значению заголовка Accept-Language
+// с учётом весов q. Если подходящего языка нет, возвращается пустая строка.
+func Match(acceptLanguage string) string {
+	best, bestWeight := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		// Учитывается только основной язык: en-US и en-GB считаются английским
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if Supported(base) && weight > bestWeight {
+			best, bestWeight = base, weight
+		}
+	}
+	return best
+}
+
+type langKey struct{}
+
+// WithLang возвращает контекст с языком запроса
+func WithLang(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// Lang возвращает язык запроса, по умолчанию русский
+func Lang(ctx context.Context) string {
+	if lang, ok := ctx.Value(langKey{}).(string); ok {
+		return lang
+	}
+	return RU
+}
+
+// Middleware определяет язык запроса: из cookie lang, затем из заголовка
+// Accept-Language, иначе используется defaultLang.
+func Middleware(defaultLang string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := ""
+		if cookie, err := r.Cookie(LangCookie); err == nil && Supported(cookie.Value) {
+			lang = cookie.Value
+		}
+		if lang == "" {
+			lang = Match(r.Header.Get("Accept-Language"))
+		}
+		if lang == "" {
+			lang = defaultLang
+		}
+
+		// Ответ зависит от заголовка, кэши должны это учитывать
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", lang)
+		next.ServeHTTP(w, r.WithContext(WithLang(r.Context(), lang)))
+	})
+}
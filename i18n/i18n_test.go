@@ -0,0 +1,111 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tbl := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"en", EN},
+		{"en-US,en;q=0.9", EN},
+		{"ru-RU, ru;q=0.9, en;q=0.8", RU},
+		{"de, en;q=0.5, ru;q=0.7", RU},
+		{"fr, de", ""},
+		{"EN-gb", EN},
+		{"en;q=bad, ru;q=0.1", RU},
+	}
+	for _, v := range tbl {
+		assert.Equal(t, v.want, Match(v.header), v.header)
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Задача не найдена", T(RU, TaskNotFound))
+	assert.Equal(t, "Task not found", T(EN, TaskNotFound))
+	assert.Equal(t, "Period cannot be longer than 366 days", T(EN, PeriodTooLong, 366))
+	// Неизвестный язык - русский текст, неизвестный ключ - сам ключ
+	assert.Equal(t, "Задача не найдена", T("de", TaskNotFound))
+	assert.Equal(t, "no_such_key", T(EN, Key("no_such_key")))
+}
+
+// TestCatalog проверяет, что у каждого сообщения есть перевод на все языки
+// с теми же подстановками, что и в русском тексте
+func TestCatalog(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	for key, texts := range messages {
+		for _, lang := range Languages {
+			text, ok := texts[lang]
+			if assert.True(t, ok, "%s: no %s text", key, lang) {
+				assert.NotEmpty(t, text, "%s: empty %s text", key, lang)
+				assert.Equal(t, verbs.FindAllString(texts[RU], -1), verbs.FindAllString(text, -1),
+					"%s: %s text has different verbs", key, lang)
+			}
+		}
+	}
+}
+
+func TestDescribeRepeat(t *testing.T) {
+	tbl := []struct {
+		repeat string
+		ru, en string
+	}{
+		{"", "", ""},
+		{"x 1", "", ""},
+		{"d 1", "каждый день", "every day"},
+		{"d 2", "каждые 2 дня", "every 2 days"},
+		{"d 7", "каждые 7 дней", "every 7 days"},
+		{"d 21", "каждый 21 день", "every 21 days"},
+		{"d 11", "каждые 11 дней", "every 11 days"},
+		{"y", "каждый год", "every year"},
+		{"w 1,3", "по понедельникам и средам", "every Monday and Wednesday"},
+		{"w 1,3,5", "по понедельникам, средам и пятницам", "every Monday, Wednesday and Friday"},
+		{"m 1,-1", "1-е и последнее числа каждого месяца", "on the 1st and last day of every month"},
+		{"m -1 2", "последнее число февраля", "on the last day of February"},
+		{"m 22 1,12", "22-е число января и декабря", "on the 22nd of January and December"},
+	}
+	for _, v := range tbl {
+		assert.Equal(t, v.ru, DescribeRepeat(RU, v.repeat), v.repeat)
+		assert.Equal(t, v.en, DescribeRepeat(EN, v.repeat), v.repeat)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got string
+	handler := Middleware(RU, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Lang(r.Context())
+	}))
+
+	tbl := []struct {
+		header, cookie string
+		want           string
+	}{
+		{"", "", RU},
+		{"en-US", "", EN},
+		{"en-US", RU, RU},
+		{"", EN, EN},
+		{"fr", "de", RU},
+	}
+	for _, v := range tbl {
+		req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+		if v.header != "" {
+			req.Header.Set("Accept-Language", v.header)
+		}
+		if v.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: LangCookie, Value: v.cookie})
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, v.want, got)
+		assert.Equal(t, v.want, rec.Header().Get("Content-Language"))
+		assert.Contains(t, rec.Header().Values("Vary"), "Accept-Language")
+	}
+}
@@ -0,0 +1,170 @@
+package i18n
+
+// Сообщения API. Аргументы подставляются в текст как в fmt.Sprintf.
+const (
+	InvalidJSON      Key = "invalid_json"
+	MethodNotAllowed Key = "method_not_allowed"
+	AuthRequired     Key = "auth_required"
+	TokenCheckFailed Key = "token_check_failed"
+	TokenFailed      Key = "token_failed"
+	WrongPassword    Key = "wrong_password"
+	WrongCredentials Key = "wrong_credentials"
+	LoginRequired    Key = "login_required"
+	PasswordTooShort Key = "password_too_short"
+	UserExists       Key = "user_exists"
+	RegisterFailed   Key = "register_failed"
+	RegisterDenied   Key = "register_denied"
+	RegisterClosed   Key = "register_closed"
+	SignInFailed     Key = "sign_in_failed"
+	FeedTokenFailed  Key = "feed_token_failed"
+
+	TaskIDRequired     Key = "task_id_required"
+	TaskIDInvalid      Key = "task_id_invalid"
+	TaskNotFound       Key = "task_not_found"
+	TaskNotInTrash     Key = "task_not_in_trash"
+	TitleRequired      Key = "title_required"
+	DateInvalid        Key = "date_invalid"
+	ParamDateInvalid   Key = "param_date_invalid"
+	RepeatInvalid      Key = "repeat_invalid"
+	PriorityInvalid    Key = "priority_invalid"
+	TimeInvalid        Key = "time_invalid"
+	TagEmpty           Key = "tag_empty"
+	TooManyTags        Key = "too_many_tags"
+	LabelComma         Key = "label_comma"
+	LabelTooLong       Key = "label_too_long"
+	ParamInvalid       Key = "param_invalid"
+	PeriodReversed     Key = "period_reversed"
+	PeriodTooLong      Key = "period_too_long"
+	NothingToUndo      Key = "nothing_to_undo"
+	TaskDeletedAfter   Key = "task_deleted_after_done"
+	TaskModified       Key = "task_modified"
+	TaskGetFailed      Key = "task_get_failed"
+	TaskAddFailed      Key = "task_add_failed"
+	TasksAddFailed     Key = "tasks_add_failed"
+	TaskUpdateFailed   Key = "task_update_failed"
+	TaskDeleteFailed   Key = "task_delete_failed"
+	TaskDoneFailed     Key = "task_done_failed"
+	TaskRestoreFailed  Key = "task_restore_failed"
+	UndoFailed         Key = "undo_failed"
+	NextDateFailed     Key = "next_date_failed"
+	TasksListFailed    Key = "tasks_list_failed"
+	LabelsListFailed   Key = "labels_list_failed"
+	TrashListFailed    Key = "trash_list_failed"
+	HistoryListFailed  Key = "history_list_failed"
+	CalendarName       Key = "calendar_name"
+	CalendarFailed     Key = "calendar_failed"
+	CalendarReadFailed Key = "calendar_read_failed"
+	CalendarInvalid    Key = "calendar_invalid"
+	CalendarTooLarge   Key = "calendar_too_large"
+	ImportDateInvalid  Key = "import_date_invalid"
+	ImportRRule        Key = "import_rrule_unsupported"
+	ImportRepeat       Key = "import_repeat_invalid"
+	ImportPast         Key = "import_past"
+
+	WebhookURLInvalid    Key = "webhook_url_invalid"
+	WebhookHostPrivate   Key = "webhook_host_private"
+	WebhookHostUnknown   Key = "webhook_host_unknown"
+	WebhookEventUnknown  Key = "webhook_event_unknown"
+	WebhookIDRequired    Key = "webhook_id_required"
+	WebhookIDInvalid     Key = "webhook_id_invalid"
+	WebhookNotFound      Key = "webhook_not_found"
+	WebhookAddFailed     Key = "webhook_add_failed"
+	WebhookDeleteFailed  Key = "webhook_delete_failed"
+	WebhooksListFailed   Key = "webhooks_list_failed"
+	DeliveryIDRequired   Key = "delivery_id_required"
+	DeliveryIDInvalid    Key = "delivery_id_invalid"
+	DeliveryNotFound     Key = "delivery_not_found"
+	DeliveriesListFailed Key = "deliveries_list_failed"
+	DeliveryRetryFailed  Key = "delivery_retry_failed"
+	EventsNotSupported   Key = "events_not_supported"
+	DatabaseUnavailable  Key = "database_unavailable"
+
+	ReminderSubject Key = "reminder_subject"
+	ReminderToday   Key = "reminder_today"
+	ReminderTodayAt Key = "reminder_today_at"
+)
+
+// messages каталог сообщений: тексты на всех языках рядом, чтобы перевод
+// не отставал от оригинала
+var messages = map[Key]map[string]string{
+	InvalidJSON:      {RU: "Неверный формат JSON", EN: "Invalid JSON"},
+	MethodNotAllowed: {RU: "Метод не поддерживается", EN: "Method not allowed"},
+	AuthRequired:     {RU: "Требуется аутентификация", EN: "Authentication required"},
+	TokenCheckFailed: {RU: "Не удалось проверить токен", EN: "Failed to verify the token"},
+	TokenFailed:      {RU: "Не удалось создать токен", EN: "Failed to create a token"},
+	WrongPassword:    {RU: "Неверный пароль", EN: "Wrong password"},
+	WrongCredentials: {RU: "Неверный логин или пароль", EN: "Wrong login or password"},
+	LoginRequired:    {RU: "Не указан логин", EN: "Login is required"},
+	PasswordTooShort: {RU: "Пароль должен содержать не менее %d символов", EN: "Password must be at least %d characters long"},
+	UserExists:       {RU: "Пользователь с таким логином уже существует", EN: "A user with this login already exists"},
+	RegisterFailed:   {RU: "Не удалось зарегистрировать пользователя", EN: "Failed to register the user"},
+	RegisterDenied:   {RU: "Регистрировать пользователей может только вошедший по общему паролю", EN: "Only a user signed in with the shared password can register users"},
+	RegisterClosed:   {RU: "Регистрация отключена", EN: "Registration is disabled"},
+	SignInFailed:     {RU: "Не удалось выполнить вход", EN: "Failed to sign in"},
+	FeedTokenFailed:  {RU: "Не удалось изменить токен подписки на календарь", EN: "Failed to update the calendar feed token"},
+
+	TaskIDRequired:     {RU: "Не указан идентификатор задачи", EN: "Task ID is required"},
+	TaskIDInvalid:      {RU: "Идентификатор задачи должен быть числом", EN: "Task ID must be a number"},
+	TaskNotFound:       {RU: "Задача не найдена", EN: "Task not found"},
+	TaskNotInTrash:     {RU: "Задача не найдена в корзине", EN: "Task not found in the trash"},
+	TitleRequired:      {RU: "Не указан заголовок задачи", EN: "Task title is required"},
+	DateInvalid:        {RU: "Неверный формат даты (ожидается YYYYMMDD)", EN: "Invalid date format (expected YYYYMMDD)"},
+	ParamDateInvalid:   {RU: "Неверный формат даты '%s' (ожидается YYYYMMDD)", EN: "Invalid date format in '%s' (expected YYYYMMDD)"},
+	RepeatInvalid:      {RU: "Некорректное правило повторения", EN: "Invalid repeat rule"},
+	PriorityInvalid:    {RU: "Приоритет должен быть от 0 до %d", EN: "Priority must be between 0 and %d"},
+	TimeInvalid:        {RU: "Неверный формат времени (ожидается HH:MM)", EN: "Invalid time format (expected HH:MM)"},
+	TagEmpty:           {RU: "Метка не может быть пустой", EN: "Tag cannot be empty"},
+	TooManyTags:        {RU: "Слишком много меток у задачи", EN: "Too many tags on the task"},
+	LabelComma:         {RU: "Название метки или проекта не может содержать запятую", EN: "Tag or project name cannot contain a comma"},
+	LabelTooLong:       {RU: "Слишком длинное название метки или проекта", EN: "Tag or project name is too long"},
+	ParamInvalid:       {RU: "Неверный параметр '%s'", EN: "Invalid parameter '%s'"},
+	PeriodReversed:     {RU: "Дата 'to' раньше даты 'from'", EN: "Date 'to' is before date 'from'"},
+	PeriodTooLong:      {RU: "Период не может быть длиннее %d дней", EN: "Period cannot be longer than %d days"},
+	NothingToUndo:      {RU: "Нет выполнения, которое можно отменить", EN: "There is no completion to undo"},
+	TaskDeletedAfter:   {RU: "Задача удалена после выполнения", EN: "The task was deleted after it was completed"},
+	TaskModified:       {RU: "Задача изменена после того, как вы её открыли, обновите её и повторите", EN: "The task was changed after you opened it, reload it and try again"},
+	TaskGetFailed:      {RU: "Ошибка при получении задачи", EN: "Failed to get the task"},
+	TaskAddFailed:      {RU: "Не удалось добавить задачу", EN: "Failed to add the task"},
+	TasksAddFailed:     {RU: "Не удалось добавить задачи", EN: "Failed to add the tasks"},
+	TaskUpdateFailed:   {RU: "Не удалось обновить задачу", EN: "Failed to update the task"},
+	TaskDeleteFailed:   {RU: "Не удалось удалить задачу", EN: "Failed to delete the task"},
+	TaskDoneFailed:     {RU: "Не удалось завершить задачу", EN: "Failed to complete the task"},
+	TaskRestoreFailed:  {RU: "Не удалось восстановить задачу", EN: "Failed to restore the task"},
+	UndoFailed:         {RU: "Не удалось отменить выполнение задачи", EN: "Failed to undo the completion"},
+	NextDateFailed:     {RU: "Ошибка при расчёте следующей даты", EN: "Failed to calculate the next date"},
+	TasksListFailed:    {RU: "Не удалось получить задачи", EN: "Failed to get tasks"},
+	LabelsListFailed:   {RU: "Не удалось получить список", EN: "Failed to get the list"},
+	TrashListFailed:    {RU: "Не удалось получить корзину", EN: "Failed to get the trash"},
+	HistoryListFailed:  {RU: "Не удалось получить историю выполнения", EN: "Failed to get the completion history"},
+	CalendarName:       {RU: "Планировщик задач", EN: "Task scheduler"},
+	CalendarFailed:     {RU: "Не удалось сформировать календарь", EN: "Failed to build the calendar"},
+	CalendarReadFailed: {RU: "Не удалось прочитать файл календаря", EN: "Failed to read the calendar file"},
+	CalendarInvalid:    {RU: "Неверный формат календаря", EN: "Invalid calendar format"},
+	CalendarTooLarge:   {RU: "Файл календаря больше %d МБ", EN: "Calendar file is larger than %d MB"},
+	ImportDateInvalid:  {RU: "Неверный формат даты: %v", EN: "Invalid date: %v"},
+	ImportRRule:        {RU: "Неподдерживаемое правило повторения: %s", EN: "Unsupported repeat rule: %s"},
+	ImportRepeat:       {RU: "Некорректное правило повторения: %s", EN: "Invalid repeat rule: %s"},
+	ImportPast:         {RU: "Событие уже прошло", EN: "The event is in the past"},
+
+	WebhookURLInvalid:    {RU: "Неверный адрес подписки (ожидается URL http или https)", EN: "Invalid webhook URL (expected an http or https URL)"},
+	WebhookHostPrivate:   {RU: "Адрес подписки ведёт во внутреннюю сеть", EN: "Webhook URL points to an internal network"},
+	WebhookHostUnknown:   {RU: "Не удалось найти адрес хоста %s", EN: "Failed to resolve host %s"},
+	WebhookEventUnknown:  {RU: "Неизвестный тип события: %s", EN: "Unknown event type: %s"},
+	WebhookIDRequired:    {RU: "Не указан идентификатор подписки", EN: "Webhook ID is required"},
+	WebhookIDInvalid:     {RU: "Идентификатор подписки должен быть числом", EN: "Webhook ID must be a number"},
+	WebhookNotFound:      {RU: "Подписка не найдена", EN: "Webhook not found"},
+	WebhookAddFailed:     {RU: "Не удалось создать подписку", EN: "Failed to create the webhook"},
+	WebhookDeleteFailed:  {RU: "Не удалось удалить подписку", EN: "Failed to delete the webhook"},
+	WebhooksListFailed:   {RU: "Не удалось получить подписки", EN: "Failed to get webhooks"},
+	DeliveryIDRequired:   {RU: "Не указан идентификатор доставки", EN: "Delivery ID is required"},
+	DeliveryIDInvalid:    {RU: "Идентификатор доставки должен быть числом", EN: "Delivery ID must be a number"},
+	DeliveryNotFound:     {RU: "Недоставленное событие не найдено", EN: "Failed delivery not found"},
+	DeliveriesListFailed: {RU: "Не удалось получить недоставленные события", EN: "Failed to get failed deliveries"},
+	DeliveryRetryFailed:  {RU: "Не удалось повторить доставку", EN: "Failed to retry the delivery"},
+	EventsNotSupported:   {RU: "Поток событий не поддерживается", EN: "Event streaming is not supported"},
+	DatabaseUnavailable:  {RU: "База данных недоступна", EN: "Database is unavailable"},
+
+	ReminderSubject: {RU: "Напоминание: %s", EN: "Reminder: %s"},
+	ReminderToday:   {RU: "%s — сегодня", EN: "%s — today"},
+	ReminderTodayAt: {RU: "%s — сегодня в %s", EN: "%s — today at %s"},
+}
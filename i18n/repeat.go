@@ -0,0 +1,179 @@
+package i18n
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go_final_project/utils"
+)
+
+// Названия дней недели и месяцев в нужных формах, индекс 0 - понедельник или январь
+var (
+	weekdaysRU = []string{"понедельникам", "вторникам", "средам", "четвергам", "пятницам", "субботам", "воскресеньям"}
+	weekdaysEN = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+	monthsRU   = []string{"января", "февраля", "марта", "апреля", "мая", "июня",
+		"июля", "августа", "сентября", "октября", "ноября", "декабря"}
+	monthsEN = []string{"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"}
+)
+
+// DescribeRepeat возвращает описание правила повторения для людей на языке lang:
+// "каждые 7 дней", "every year". Для пустого или неверного правила
+// возвращается пустая строка.
+func DescribeRepeat(lang, repeat string) string {
+	if repeat == "" {
+		return ""
+	}
+	rule, err := utils.ParseRepeat(repeat)
+	if err != nil {
+		return ""
+	}
+	if lang == EN {
+		return describeEN(rule)
+	}
+	return describeRU(rule)
+}
+
+func describeRU(rule utils.RepeatRule) string {
+	switch rule.Kind {
+	case "d":
+		if rule.Days == 1 {
+			return "каждый день"
+		}
+		days := strconv.Itoa(rule.Days)
+		switch pluralRU(rule.Days) {
+		case 0:
+			return "каждый " + days + " день"
+		case 1:
+			return "каждые " + days + " дня"
+		default:
+			return "каждые " + days + " дней"
+		}
+	case "y":
+		return "каждый год"
+	case "w":
+		names := make([]string, 0, len(rule.Weekdays))
+		for _, day := range rule.Weekdays {
+			names = append(names, weekdaysRU[day-1])
+		}
+		return "по " + joinList(names, " и ")
+	case "m":
+		days := make([]string, 0, len(rule.MonthDays))
+		for _, day := range monthDays(rule.MonthDays) {
+			switch day {
+			case -1:
+				days = append(days, "последнее")
+			case -2:
+				days = append(days, "предпоследнее")
+			default:
+				days = append(days, strconv.Itoa(day)+"-е")
+			}
+		}
+		noun := "число"
+		if len(days) > 1 {
+			noun = "числа"
+		}
+		months := "каждого месяца"
+		if len(rule.Months) > 0 {
+			names := make([]string, 0, len(rule.Months))
+			for _, month := range rule.Months {
+				names = append(names, monthsRU[month-1])
+			}
+			months = joinList(names, " и ")
+		}
+		return joinList(days, " и ") + " " + noun + " " + months
+	}
+	return ""
+}
+
+func describeEN(rule utils.RepeatRule) string {
+	switch rule.Kind {
+	case "d":
+		if rule.Days == 1 {
+			return "every day"
+		}
+		return "every " + strconv.Itoa(rule.Days) + " days"
+	case "y":
+		return "every year"
+	case "w":
+		names := make([]string, 0, len(rule.Weekdays))
+		for _, day := range rule.Weekdays {
+			names = append(names, weekdaysEN[day-1])
+		}
+		return "every " + joinList(names, " and ")
+	case "m":
+		days := make([]string, 0, len(rule.MonthDays))
+		for _, day := range monthDays(rule.MonthDays) {
+			switch day {
+			case -1:
+				days = append(days, "last day")
+			case -2:
+				days = append(days, "second to last day")
+			default:
+				days = append(days, ordinalEN(day))
+			}
+		}
+		months := "every month"
+		if len(rule.Months) > 0 {
+			names := make([]string, 0, len(rule.Months))
+			for _, month := range rule.Months {
+				names = append(names, monthsEN[month-1])
+			}
+			months = joinList(names, " and ")
+		}
+		return "on the " + joinList(days, " and ") + " of " + months
+	}
+	return ""
+}
+
+// monthDays возвращает дни месяца в порядке чтения: сначала числа,
+// затем предпоследний и последний день
+func monthDays(days []int) []int {
+	ordered := slices.Clone(days)
+	slices.SortFunc(ordered, func(a, b int) int {
+		if (a < 0) != (b < 0) {
+			return cmp.Compare(b, a)
+		}
+		return cmp.Compare(a, b)
+	})
+	return ordered
+}
+
+// pluralRU возвращает форму русского слова для числа n:
+// 0 - "день" (1, 21), 1 - "дня" (2-4, 22), 2 - "дней" (5-20, 25)
+func pluralRU(n int) int {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return 0
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ordinalEN возвращает английское порядковое числительное: 1st, 2nd, 11th, 23rd
+func ordinalEN(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// joinList перечисляет элементы через запятую, последний - через last
+func joinList(items []string, last string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + last + items[len(items)-1]
+}
@@ -0,0 +1,156 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go_final_project/models"
+	"go_final_project/utils"
+)
+
+// ProdID идентификатор программы, создавшей календарь
+const ProdID = "-//go_final_project//Task scheduler//RU"
+
+// uidDomain домен в UID событий. UID строится из ID задачи,
+// поэтому при повторной загрузке клиенты обновляют события, а не дублируют их.
+const uidDomain = "todo-scheduler"
+
+// maxLineLength максимальная длина строки в октетах (без CRLF)
+const maxLineLength = 75
+
+// weekdays дни недели iCalendar в порядке правила w (1 - понедельник)
+var weekdays = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// WriteCalendar записывает задачи в w как VCALENDAR с событиями на весь день.
+// Задача с правилом повторения, которое нельзя перевести в RRULE, записывается без повторения.
+func WriteCalendar(w io.Writer, name string, tasks []models.Task, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(fold(s))
+		bw.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + ProdID)
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	if name != "" {
+		line("X-WR-CALNAME:" + escapeText(name))
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, task := range tasks {
+		// Одна задача с неверным правилом не должна ломать подписку на весь календарь:
+		// она попадает в календарь разовым событием
+		rrule, err := RRule(task.Repeat)
+		if err != nil {
+			slog.Warn("task repeat is not exported to calendar", "task_id", task.ID, "repeat", task.Repeat, "error", err)
+			rrule = ""
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:" + UID(task.ID))
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + task.Date)
+		line("SUMMARY:" + escapeText(task.Title))
+		if task.Comment != "" {
+			line("DESCRIPTION:" + escapeText(task.Comment))
+		}
+		if rrule != "" {
+			line("RRULE:" + rrule)
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// UID возвращает постоянный идентификатор события для задачи.
+func UID(taskID string) string {
+	return "task-" + taskID + "@" + uidDomain
+}
+
+// RRule переводит правило повторения задачи в значение свойства RRULE.
+// Для задачи без повторения возвращается пустая строка.
+func RRule(repeat string) (string, error) {
+	if repeat == "" {
+		return "", nil
+	}
+
+	rule, err := utils.ParseRepeat(repeat)
+	if err != nil {
+		return "", err
+	}
+
+	switch rule.Kind {
+	case "d":
+		return "FREQ=DAILY;INTERVAL=" + strconv.Itoa(rule.Days), nil
+	case "y":
+		return "FREQ=YEARLY", nil
+	case "w":
+		days := make([]string, 0, len(rule.Weekdays))
+		for _, day := range rule.Weekdays {
+			days = append(days, weekdays[day-1])
+		}
+		return "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ","), nil
+	case "m":
+		rrule := "FREQ=MONTHLY;BYMONTHDAY=" + joinInts(rule.MonthDays)
+		if len(rule.Months) > 0 {
+			rrule += ";BYMONTH=" + joinInts(rule.Months)
+		}
+		return rrule, nil
+	}
+	return "", fmt.Errorf("unsupported repeat rule: %s", repeat)
+}
+
+func joinInts(list []int) string {
+	parts := make([]string, 0, len(list))
+	for _, n := range list {
+		parts = append(parts, strconv.Itoa(n))
+	}
+	return strings.Join(parts, ",")
+}
+
+// escapeText экранирует значение типа TEXT (RFC 5545, 3.3.11).
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// fold переносит строку длиннее 75 октетов (RFC 5545, 3.1),
+// не разрывая многобайтовые символы UTF-8.
+func fold(s string) string {
+	if len(s) <= maxLineLength {
+		return s
+	}
+
+	var b strings.Builder
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Строка продолжения начинается с пробела, который тоже занимает октет
+		limit = maxLineLength - 1
+	}
+	b.WriteString(s)
+	return b.String()
+}
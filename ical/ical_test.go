@@ -0,0 +1,224 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/models"
+)
+
+func TestRRule(t *testing.T) {
+	for _, tc := range []struct {
+		repeat string
+		rrule  string
+	}{
+		{"", ""},
+		{"d 1", "FREQ=DAILY;INTERVAL=1"},
+		{"d 400", "FREQ=DAILY;INTERVAL=400"},
+		{"y", "FREQ=YEARLY"},
+		{"w 7", "FREQ=WEEKLY;BYDAY=SU"},
+		{"w 1,2,3,4,5", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{"m 15", "FREQ=MONTHLY;BYMONTHDAY=15"},
+		{"m -1,-2", "FREQ=MONTHLY;BYMONTHDAY=-2,-1"},
+		{"m 1,15 3,9", "FREQ=MONTHLY;BYMONTHDAY=1,15;BYMONTH=3,9"},
+	} {
+		rrule, err := RRule(tc.repeat)
+		if assert.NoError(t, err, tc.repeat) {
+			assert.Equal(t, tc.rrule, rrule, tc.repeat)
+		}
+	}
+
+	for _, repeat := range []string{"x", "d", "d 0", "d 401", "w", "w 8", "m 32", "m -3", "m 1 13"} {
+		_, err := RRule(repeat)
+		assert.Error(t, err, repeat)
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	// 15 января 2024 - понедельник
+	const start = "20240115"
+	for _, tc := range []struct {
+		rrule  string
+		repeat string
+	}{
+		{"", ""},
+		{"FREQ=DAILY", "d 1"},
+		{"FREQ=DAILY;INTERVAL=3", "d 3"},
+		{"freq=daily;interval=3", "d 3"},
+		{"FREQ=WEEKLY", "d 7"},
+		{"FREQ=WEEKLY;INTERVAL=2", "d 14"},
+		{"FREQ=WEEKLY;BYDAY=MO,FR;WKST=MO", "w 1,5"},
+		{"FREQ=MONTHLY", "m 15"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "m -1"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-2;BYMONTH=3,9", "m 1,-2 3,9"},
+		{"FREQ=YEARLY", "y"},
+		{"FREQ=YEARLY;BYMONTH=6", "m 15 6"},
+		{"FREQ=YEARLY;BYMONTHDAY=10", "m 10 1"},
+		{"FREQ=YEARLY;BYMONTHDAY=10;BYMONTH=2,8", "m 10 2,8"},
+	} {
+		repeat, err := Repeat(tc.rrule, start)
+		if assert.NoError(t, err, tc.rrule) {
+			assert.Equal(t, tc.repeat, repeat, tc.rrule)
+		}
+	}
+
+	// Правила, которые нельзя выразить правилами планировщика, отклоняются, а не упрощаются
+	for _, rrule := range []string{
+		"FREQ=DAILY;COUNT=5",
+		"FREQ=WEEKLY;BYDAY=MO;UNTIL=20240301T000000Z",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=500",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=2TU",
+		"FREQ=MONTHLY;INTERVAL=2",
+		"FREQ=MONTHLY;BYDAY=MO;BYSETPOS=1",
+		"FREQ=MONTHLY;BYMONTHDAY=-3",
+		"FREQ=YEARLY;INTERVAL=2",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ",
+		"INTERVAL=2",
+	} {
+		_, err := Repeat(rrule, start)
+		assert.ErrorIs(t, err, ErrUnsupportedRRule, rrule)
+	}
+
+	_, err := Repeat("FREQ=DAILY", "15.01.2024")
+	assert.Error(t, err)
+}
+
+func TestRRuleRoundTrip(t *testing.T) {
+	// Правило каждого вида возвращается из RRULE без изменений. Списки записаны
+	// по возрастанию: так их упорядочивает разбор правила.
+	for _, repeat := range []string{
+		"d 1", "d 7", "d 400",
+		"y",
+		"w 1", "w 7", "w 1,3,5",
+		"m 1", "m 31", "m -1", "m -2", "m -1,1,15", "m 10 1", "m -2,1 3,9,12",
+	} {
+		rrule, err := RRule(repeat)
+		require.NoError(t, err, repeat)
+		got, err := Repeat(rrule, "20240115")
+		if assert.NoError(t, err, rrule) {
+			assert.Equal(t, repeat, got, rrule)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:event@example.com",
+		"DTSTART;VALUE=DATE:20240115",
+		`SUMMARY:Отчёт\, квартал\; итоги`,
+		`DESCRIPTION:Первая строка\nвторая \\ строка`,
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"BEGIN:VALARM",
+		"DESCRIPTION:Напоминание",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:folded@example.com",
+		"DTSTART;TZID=\"Europe/Moscow: MSK\":20240116T093000",
+		"SUMMARY:Очень длинный заголовок, перенесённый",
+		"  на следующую строку",
+		"\tи ещё раз",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:todo@example.com",
+		"DUE;VALUE=DATE:20240117",
+		"SUMMARY:Задача со сроком",
+		"END:VTODO",
+		"BEGIN:VEVENT",
+		"UID:nostart@example.com",
+		"SUMMARY:Без даты",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:baddate@example.com",
+		"DTSTART:2024-01-18",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	items, err := Parse(strings.NewReader(ics))
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+
+	assert.Equal(t, Item{
+		Kind:        "VEVENT",
+		UID:         "event@example.com",
+		Summary:     "Отчёт, квартал; итоги",
+		Description: "Первая строка\nвторая \\ строка",
+		Start:       "20240115",
+		RRule:       "FREQ=WEEKLY;BYDAY=MO",
+	}, items[0])
+
+	// Строки продолжения склеиваются, двоеточие в кавычках не отделяет значение
+	assert.Equal(t, "Очень длинный заголовок, перенесённый на следующую строкуи ещё раз", items[1].Summary)
+	assert.Equal(t, "20240116", items[1].Start)
+	assert.NoError(t, items[1].Err)
+
+	assert.Equal(t, "VTODO", items[2].Kind)
+	assert.Equal(t, "20240117", items[2].Start)
+
+	assert.EqualError(t, items[3].Err, "no start date")
+	assert.ErrorContains(t, items[4].Err, "invalid DTSTART")
+
+	_, err = Parse(strings.NewReader("BEGIN:VEVENT\r\nEND:VEVENT\r\n"))
+	assert.Error(t, err)
+}
+
+func TestWriteCalendar(t *testing.T) {
+	title := strings.Repeat("Подготовить отчёт, ", 5) + "итоги; выводы"
+	comment := "Первая строка\nвторая \\ строка"
+	var buf bytes.Buffer
+	require.NoError(t, WriteCalendar(&buf, "Задачи", []models.Task{
+		{ID: "7", Date: "20240115", Title: title, Comment: comment, Repeat: "m -1 3,9"},
+	}, time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)))
+	ics := buf.String()
+
+	// Строки не длиннее 75 октетов и не разрывают символы UTF-8
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineLength, line)
+		assert.True(t, utf8.ValidString(line), line)
+	}
+	assert.Contains(t, ics, "DTSTAMP:20240110T120000Z\r\n")
+	assert.Contains(t, ics, "X-WR-CALNAME:Задачи\r\n")
+
+	items, err := Parse(&buf)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, UID("7"), items[0].UID)
+	assert.Equal(t, title, items[0].Summary)
+	assert.Equal(t, comment, items[0].Description)
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=-1;BYMONTH=3,9", items[0].RRule)
+}
+
+func TestWriteCalendarBadRepeat(t *testing.T) {
+	tasks := []models.Task{
+		{ID: "1", Date: "20240115", Title: "Отчёт", Repeat: "d 7"},
+		{ID: "2", Date: "20240116", Title: "Старая задача", Repeat: "x 3"},
+		{ID: "3", Date: "20240117", Title: "Встреча", Repeat: "w 1,3"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteCalendar(&buf, "Задачи", tasks, time.Now()))
+
+	// Задача с неверным правилом остаётся в календаре разовым событием
+	items, err := Parse(&buf)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, "FREQ=DAILY;INTERVAL=7", items[0].RRule)
+	assert.Equal(t, "Старая задача", items[1].Summary)
+	assert.Equal(t, "20240116", items[1].Start)
+	assert.Empty(t, items[1].RRule)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", items[2].RRule)
+}
@@ -0,0 +1,335 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_final_project/constants"
+	"go_final_project/utils"
+)
+
+// ErrUnsupportedRRule возвращается для правил RRULE, которые нельзя
+// выразить правилами повторения планировщика.
+var ErrUnsupportedRRule = errors.New("unsupported RRULE")
+
+// Item событие (VEVENT) или задача (VTODO) из календаря.
+type Item struct {
+	Kind        string // VEVENT или VTODO
+	UID         string
+	Summary     string
+	Description string
+	Start       string // DTSTART (для VTODO без него - DUE) в формате YYYYMMDD
+	RRule       string
+	Err         error // ошибка разбора элемента, остальные поля могут быть неполными
+}
+
+// Parse читает календарь и возвращает его события и задачи.
+// Ошибки отдельных элементов сохраняются в Item.Err, ошибка возвращается
+// только если данные вообще не похожи на календарь.
+func Parse(r io.Reader) ([]Item, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		items   []Item
+		current *Item
+		due     string
+		depth   int // вложенность компонентов внутри текущего элемента (например, VALARM)
+		found   bool
+	)
+	for _, line := range lines {
+		name, params, value, ok := splitLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			found = true
+			continue
+		case name == "BEGIN" && current == nil && (value == "VEVENT" || value == "VTODO"):
+			current = &Item{Kind: value}
+			due = ""
+			continue
+		case current == nil:
+			continue
+		case name == "BEGIN":
+			depth++
+			continue
+		case name == "END" && depth > 0:
+			depth--
+			continue
+		case name == "END" && value == current.Kind:
+			if current.Start == "" && current.Err == nil {
+				current.Start = due
+			}
+			if current.Start == "" && current.Err == nil {
+				current.Err = errors.New("no start date")
+			}
+			items = append(items, *current)
+			current = nil
+			continue
+		case depth > 0:
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.UID = value
+		case "SUMMARY":
+			current.Summary = unescapeText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case "RRULE":
+			current.RRule = value
+		case "DTSTART", "DUE":
+			date, err := parseDate(params, value)
+			if err != nil {
+				current.Err = fmt.Errorf("invalid %s: %w", name, err)
+			} else if name == "DTSTART" {
+				current.Start = date
+			} else {
+				due = date
+			}
+		}
+	}
+
+	if !found {
+		return nil, errors.New("not an iCalendar document")
+	}
+	return items, nil
+}
+
+// unfold читает строки, склеивая перенесённые (RFC 5545, 3.1).
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitLine разбирает строку вида NAME;PARAM=VALUE:value.
+// Двоеточия внутри значений параметров в кавычках не считаются разделителем.
+func splitLine(line string) (name string, params map[string]string, value string, ok bool) {
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	name = strings.ToUpper(parts[0])
+	params = map[string]string{}
+	for _, p := range parts[1:] {
+		key, val, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+	return name, params, line[colon+1:], true
+}
+
+// parseDate переводит значение DATE или DATE-TIME в дату YYYYMMDD.
+// Время в UTC переводится в местный часовой пояс, время с TZID
+// и плавающее время берутся как есть.
+func parseDate(params map[string]string, value string) (string, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(constants.DateFormat) {
+		date, err := time.Parse(constants.DateFormat, value)
+		if err != nil {
+			return "", err
+		}
+		return date.Format(constants.DateFormat), nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return "", err
+		}
+		return t.Local().Format(constants.DateFormat), nil
+	}
+
+	t, err := time.Parse("20060102T150405", value)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(constants.DateFormat), nil
+}
+
+// unescapeText снимает экранирование значения типа TEXT.
+func unescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Repeat переводит RRULE в правило повторения планировщика.
+// start - дата первого повторения в формате YYYYMMDD, из неё берутся
+// день недели и число месяца, если они не указаны в правиле.
+// Правила с COUNT и UNTIL, интервалами, которые нельзя выразить в днях,
+// и днями недели с номером (например, 2TU) не поддерживаются.
+func Repeat(rrule, start string) (string, error) {
+	if rrule == "" {
+		return "", nil
+	}
+
+	startDate, err := time.Parse(constants.DateFormat, start)
+	if err != nil {
+		return "", err
+	}
+
+	parts := map[string]string{}
+	for _, part := range strings.Split(rrule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnsupportedRRule, rrule)
+		}
+		parts[strings.ToUpper(key)] = strings.ToUpper(value)
+	}
+	delete(parts, "WKST")
+
+	unsupported := func() (string, error) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedRRule, rrule)
+	}
+	if _, ok := parts["COUNT"]; ok {
+		return unsupported()
+	}
+	if _, ok := parts["UNTIL"]; ok {
+		return unsupported()
+	}
+
+	interval := 1
+	if value, ok := parts["INTERVAL"]; ok {
+		interval, err = strconv.Atoi(value)
+		if err != nil || interval <= 0 {
+			return unsupported()
+		}
+	}
+	freq := parts["FREQ"]
+	delete(parts, "FREQ")
+	delete(parts, "INTERVAL")
+
+	var repeat string
+	switch freq {
+	case "DAILY":
+		if len(parts) > 0 {
+			return unsupported()
+		}
+		repeat = "d " + strconv.Itoa(interval)
+
+	case "WEEKLY":
+		byDay, ok := parts["BYDAY"]
+		delete(parts, "BYDAY")
+		if len(parts) > 0 {
+			return unsupported()
+		}
+		if !ok {
+			repeat = "d " + strconv.Itoa(7*interval)
+			break
+		}
+		if interval != 1 {
+			return unsupported()
+		}
+		days, err := weekdayNumbers(byDay)
+		if err != nil {
+			return unsupported()
+		}
+		repeat = "w " + joinInts(days)
+
+	case "MONTHLY":
+		byMonthDay, ok := parts["BYMONTHDAY"]
+		if !ok {
+			byMonthDay = strconv.Itoa(startDate.Day())
+		}
+		byMonth, hasMonths := parts["BYMONTH"]
+		delete(parts, "BYMONTHDAY")
+		delete(parts, "BYMONTH")
+		if len(parts) > 0 || interval != 1 {
+			return unsupported()
+		}
+		repeat = "m " + byMonthDay
+		if hasMonths {
+			repeat += " " + byMonth
+		}
+
+	case "YEARLY":
+		byMonthDay, hasDays := parts["BYMONTHDAY"]
+		byMonth, hasMonths := parts["BYMONTH"]
+		delete(parts, "BYMONTHDAY")
+		delete(parts, "BYMONTH")
+		if len(parts) > 0 || interval != 1 {
+			return unsupported()
+		}
+		if !hasDays && !hasMonths {
+			repeat = "y"
+			break
+		}
+		if !hasDays {
+			byMonthDay = strconv.Itoa(startDate.Day())
+		}
+		if !hasMonths {
+			byMonth = strconv.Itoa(int(startDate.Month()))
+		}
+		repeat = "m " + byMonthDay + " " + byMonth
+
+	default:
+		return unsupported()
+	}
+
+	if _, err := utils.ParseRepeat(repeat); err != nil {
+		return unsupported()
+	}
+	return repeat, nil
+}
+
+// weekdayNumbers переводит BYDAY (MO,TU,...) в номера дней недели 1-7.
+func weekdayNumbers(byDay string) ([]int, error) {
+	var days []int
+	for _, day := range strings.Split(byDay, ",") {
+		n := -1
+		for i, name := range weekdays {
+			if day == name {
+				n = i + 1
+			}
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("unsupported BYDAY value: %s", day)
+		}
+		days = append(days, n)
+	}
+	return days, nil
+}
@@ -0,0 +1,60 @@
+// Package jobs содержит фоновые задачи сервера.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go_final_project/db"
+	"go_final_project/metrics"
+)
+
+// PurgeStore данные, которые удаляет Purge
+type PurgeStore interface {
+	db.TrashStore
+	db.WebhookStore
+}
+
+// Purge периодически удаляет из корзины задачи, пролежавшие там дольше trashRetention,
+// и доставленные события вебхуков старше deliveryRetention. Нулевой срок отключает
+// соответствующую очистку. Первая очистка выполняется сразу, следующие - раз в interval,
+// до отмены ctx.
+func Purge(ctx context.Context, store PurgeStore, trashRetention, deliveryRetention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if trashRetention > 0 {
+			purged, err := store.PurgeTrash(ctx, time.Now().Add(-trashRetention))
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to purge trash", "error", err)
+				metrics.JobRun("purge_trash", metrics.OutcomeError)
+			} else {
+				if purged > 0 {
+					slog.InfoContext(ctx, "trash purged", "tasks", purged)
+				}
+				metrics.JobRun("purge_trash", metrics.OutcomeSuccess)
+			}
+		}
+
+		if deliveryRetention > 0 {
+			purged, err := store.PurgeDeliveries(ctx, time.Now().Add(-deliveryRetention))
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to purge webhook deliveries", "error", err)
+				metrics.JobRun("purge_deliveries", metrics.OutcomeError)
+			} else {
+				if purged > 0 {
+					slog.InfoContext(ctx, "webhook deliveries purged", "deliveries", purged)
+				}
+				metrics.JobRun("purge_deliveries", metrics.OutcomeSuccess)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"go_final_project/constants"
+	"go_final_project/db"
+	"go_final_project/metrics"
+	"go_final_project/notify"
+)
+
+// Reminders рассылает напоминания о задачах на сегодня. Задача без времени
+// напоминается при первой проверке за день, задача со временем - за Before до него.
+// Неудачная отправка повторяется при следующей проверке.
+// Каналы ведут к контактам одного человека, поэтому напоминания приходят только
+// о задачах пользователя UserID: задачи других пользователей не должны попадать к нему.
+type Reminders struct {
+	Store     db.ReminderStore
+	Notifiers []notify.Notifier
+	UserID    int64         // владелец контактов в каналах
+	Lang      string        // язык напоминаний
+	Before    time.Duration // за сколько до времени задачи напоминать
+	Interval  time.Duration // как часто проверять задачи
+}
+
+// Run проверяет задачи раз в Interval до отмены ctx.
+func (r *Reminders) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.Dispatch(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch отправляет напоминания, срок которых наступил к моменту now.
+func (r *Reminders) Dispatch(ctx context.Context, now time.Time) {
+	today := now.Format(constants.DateFormat)
+	for _, notifier := range r.Notifiers {
+		tasks, err := r.Store.PendingReminders(ctx, r.UserID, today, notifier.Name())
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get tasks for reminders", "error", err)
+			metrics.JobRun("reminders", metrics.OutcomeError)
+			return
+		}
+
+		for _, task := range tasks {
+			if !r.due(task.Time, now) {
+				continue
+			}
+
+			if err := notifier.Notify(ctx, notify.NewMessage(r.Lang, r.UserID, task)); err != nil {
+				slog.ErrorContext(ctx, "failed to send reminder",
+					"task_id", task.ID, "channel", notifier.Name(), "error", err)
+				metrics.JobRun("reminders", metrics.OutcomeError)
+				continue
+			}
+
+			id, _ := strconv.ParseInt(task.ID, 10, 64)
+			if err := r.Store.MarkReminderSent(ctx, id, today, notifier.Name()); err != nil {
+				slog.ErrorContext(ctx, "failed to mark reminder as sent", "task_id", task.ID, "error", err)
+				metrics.JobRun("reminders", metrics.OutcomeError)
+				continue
+			}
+			slog.InfoContext(ctx, "reminder sent", "task_id", task.ID, "channel", notifier.Name())
+			metrics.JobRun("reminders", metrics.OutcomeSuccess)
+		}
+	}
+}
+
+// due проверяет, пора ли напоминать о задаче со временем taskTime (HH:MM) в момент now
+func (r *Reminders) due(taskTime string, now time.Time) bool {
+	if taskTime == "" {
+		return true
+	}
+	parsed, err := time.Parse("15:04", taskTime)
+	if err != nil {
+		return true
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), 0, 0, now.Location())
+	return !now.Before(at.Add(-r.Before))
+}
@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go_final_project/models"
+	"go_final_project/notify"
+)
+
+// userTask задача вместе с её владельцем
+type userTask struct {
+	userID int64
+	task   models.Task
+}
+
+// memoryReminders хранит задачи и отправленные напоминания в памяти
+type memoryReminders struct {
+	tasks []userTask
+	sent  map[string]bool
+}
+
+func (m *memoryReminders) PendingReminders(ctx context.Context, userID int64, date, channel string) ([]models.Task, error) {
+	var pending []models.Task
+	for _, t := range m.tasks {
+		if t.userID == userID && t.task.Date == date && !m.sent[t.task.ID+"/"+date+"/"+channel] {
+			pending = append(pending, t.task)
+		}
+	}
+	return pending, nil
+}
+
+func (m *memoryReminders) MarkReminderSent(ctx context.Context, taskID int64, date, channel string) error {
+	m.sent[strconv.FormatInt(taskID, 10)+"/"+date+"/"+channel] = true
+	return nil
+}
+
+// recorder запоминает отправленные напоминания и может имитировать сбой
+type recorder struct {
+	sent     []string
+	subjects []string
+	fail     bool
+}
+
+func (r *recorder) Name() string { return "test" }
+
+func (r *recorder) Notify(ctx context.Context, msg notify.Message) error {
+	if r.fail {
+		return errors.New("channel is down")
+	}
+	r.sent = append(r.sent, msg.Task.ID)
+	r.subjects = append(r.subjects, msg.Subject)
+	return nil
+}
+
+func TestRemindersDispatch(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 50, 0, 0, time.Local)
+	store := &memoryReminders{
+		sent: map[string]bool{},
+		tasks: []userTask{
+			{1, models.Task{ID: "1", Date: "20240115", Title: "Без времени"}},
+			{1, models.Task{ID: "2", Date: "20240115", Title: "Скоро", Time: "10:00"}},
+			{1, models.Task{ID: "3", Date: "20240115", Title: "Вечером", Time: "18:00"}},
+			{1, models.Task{ID: "4", Date: "20240116", Title: "Завтра"}},
+			{2, models.Task{ID: "5", Date: "20240115", Title: "Чужая задача"}},
+		},
+	}
+	channel := &recorder{fail: true}
+	reminders := &Reminders{
+		Store:     store,
+		Notifiers: []notify.Notifier{channel},
+		UserID:    1,
+		Lang:      "en",
+		Before:    15 * time.Minute,
+	}
+
+	// При сбое канала напоминания не считаются отправленными
+	reminders.Dispatch(context.Background(), now)
+	assert.Empty(t, store.sent)
+
+	channel.fail = false
+	reminders.Dispatch(context.Background(), now)
+	assert.Equal(t, []string{"1", "2"}, channel.sent)
+	assert.Equal(t, "Reminder: Без времени", channel.subjects[0])
+
+	// Повторная проверка не отправляет напоминания ещё раз
+	reminders.Dispatch(context.Background(), now.Add(time.Minute))
+	assert.Equal(t, []string{"1", "2"}, channel.sent)
+
+	reminders.Dispatch(context.Background(), time.Date(2024, 1, 15, 17, 45, 0, 0, time.Local))
+	assert.Equal(t, []string{"1", "2", "3"}, channel.sent)
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capture подменяет журнал по умолчанию на JSON-журнал в буфер
+func capture(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	require.NoError(t, Setup(&buf, level, "json"))
+	return &buf
+}
+
+// records разбирает записи журнала
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "verbose", "text")
+	assert.Error(t, err)
+	_, err = New(&bytes.Buffer{}, "info", "xml")
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", "text")
+	require.NoError(t, err)
+	logger.Info("skipped")
+	logger.Warn("written", "key", "value")
+	assert.NotContains(t, buf.String(), "skipped")
+	assert.Contains(t, buf.String(), "key=value")
+}
+
+func TestMiddleware(t *testing.T) {
+	buf := capture(t, "debug")
+
+	var handlerID string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerID = RequestID(r.Context())
+		slog.DebugContext(r.Context(), "inside handler")
+		_, flushable := w.(http.Flusher)
+		assert.True(t, flushable)
+		RecordError(w, "Не указан идентификатор задачи")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"..."}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/task?id=", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	require.NotEmpty(t, handlerID)
+	assert.Equal(t, handlerID, resp.Header().Get(HeaderRequestID))
+
+	logs := records(t, buf)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "inside handler", logs[0]["msg"])
+	assert.Equal(t, handlerID, logs[0]["request_id"])
+
+	request := logs[1]
+	assert.Equal(t, "request", request["msg"])
+	assert.Equal(t, "WARN", request["level"])
+	assert.Equal(t, handlerID, request["request_id"])
+	assert.Equal(t, "GET", request["method"])
+	assert.Equal(t, "/api/task", request["path"])
+	assert.Equal(t, float64(http.StatusBadRequest), request["status"])
+	assert.Equal(t, float64(15), request["bytes"])
+	assert.Equal(t, "Не указан идентификатор задачи", request["error"])
+	assert.Contains(t, request, "duration")
+}
+
+func TestMiddlewareRequestIDHeader(t *testing.T) {
+	capture(t, "info")
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestID, "trace-42")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, "trace-42", resp.Header().Get(HeaderRequestID))
+
+	// Небезопасный для журнала идентификатор заменяется новым
+	req.Header.Set(HeaderRequestID, "bad id\nforged=1")
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.NotEqual(t, "bad id\nforged=1", resp.Header().Get(HeaderRequestID))
+	assert.Len(t, resp.Header().Get(HeaderRequestID), 16)
+}
@@ -1,61 +1,285 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"go_final_project/auth"
+	"go_final_project/config"
 	"go_final_project/db"
+	"go_final_project/events"
 	"go_final_project/handlers"
+	"go_final_project/i18n"
+	"go_final_project/jobs"
+	"go_final_project/logging"
+	"go_final_project/metrics"
+	"go_final_project/notify"
+	"go_final_project/webhooks"
 )
 
+const usage = `Usage: go_final_project [command] [flags] [args]
+
+Commands:
+  serve         start the server (default)
+  migrate       manage database schema: migrate [flags] up [N] | down [N] | status
+  backup        save a copy of the SQLite database: backup [flags] [FILE]
+  check-config  validate settings and print the effective configuration
+
+Run "<command> -h" to list the flags.
+`
+
+// Таймауты HTTP-сервера. Чтение запроса ограничено с запасом на импорт файлов до 5 МБ,
+// для потока /api/events таймаут записи снимается обработчиком.
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 2 * time.Minute
+)
+
+// openStore подключается к базе данных; тесты подменяют её, чтобы проверить закрытие базы
+var openStore = db.Open
+
+// commands подкоманды сервера, получают настройки и оставшиеся аргументы
+var commands = map[string]func(cfg *config.Config, args []string) error{
+	"serve":        runServe,
+	"migrate":      runMigrate,
+	"backup":       runBackup,
+	"check-config": runCheckConfig,
+}
+
 func main() {
+	// Без подкоманды запускается сервер
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	// При неверных настройках журнала остаётся журнал по умолчанию, ошибку покажет Validate
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		slog.Warn("invalid logging configuration", "error", err)
+	}
+
+	if err := command(cfg, fs.Args()); err != nil {
+		slog.Error(name+" failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runServe запускает веб-сервер и фоновые задачи
+func runServe(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// Каждый маршрут учитывается в метриках под своим шаблоном
+	mux := http.NewServeMux()
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.Handle(pattern, metrics.Instrument(pattern, handler))
+	}
 	// Указываем директорию для файлов фронтенда
-	webDir := "./web"
-	http.Handle("/", http.FileServer(http.Dir(webDir)))
+	handle("/", http.FileServer(http.Dir(cfg.WebDir)).ServeHTTP)
+
+	// Подключаемся к PostgreSQL, если задана строка подключения, иначе к файлу SQLite
+	store, err := openStore(cfg.DatabaseURL, cfg.DBFile)
+	if err != nil {
+		return fmt.Errorf("не удалось подключиться к базе данных: %w", err)
+	}
+	defer store.Close() // Закрываем подключение при завершении программы
+
+	// Создаём базу данных при необходимости и применяем миграции
+	if err := store.Migrate(); err != nil {
+		return fmt.Errorf("error with database: %w", err)
+	}
+
+	// Дальше хранилище используется через обёртку, замеряющую длительность запросов к базе
+	instrumented := metrics.InstrumentStore(store)
+	metrics.RegisterTaskCounts(instrumented)
 
-	// Инициализация пути к базе данных
-	dbPath := os.Getenv("TODO_DBFILE")
-	if dbPath == "" {
-		workingDir, err := os.Getwd()
+	// Ключ подписи токенов: из настроек или сгенерированный и сохранённый в базе
+	secret := cfg.JWTSecret
+	if secret == "" {
+		secret, err = store.TokenSecret(context.Background())
 		if err != nil {
-			log.Fatalf("Не удалось получить рабочую директорию: %v", err)
+			return fmt.Errorf("не удалось получить ключ подписи токенов: %w", err)
 		}
-		dbPath = filepath.Join(workingDir, "scheduler.db")
 	}
 
-	// Проверяем и создаём базу данных при необходимости
-	if err := db.SetupDatabase(dbPath); err != nil {
-		log.Fatalf("Error with database: %v", err)
+	// Вход по общему паролю включается заданием пароля (Задача со звёздочкой)
+	authenticator := auth.New(cfg.Password, secret)
+
+	// Фоновые задачи работают до отмены jobsCtx, при остановке сервер дожидается их завершения
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	var workers sync.WaitGroup
+	start := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(jobsCtx)
+		}()
+	}
+
+	// Задачи из корзины удаляются окончательно через TrashDays дней, доставленные
+	// события вебхуков - через WebhookDays дней (0 - не удалять)
+	if cfg.TrashDays > 0 || cfg.WebhookDays > 0 {
+		start(func(ctx context.Context) {
+			jobs.Purge(ctx, instrumented, time.Duration(cfg.TrashDays)*24*time.Hour,
+				time.Duration(cfg.WebhookDays)*24*time.Hour, time.Hour)
+		})
+	}
+
+	// Напоминания рассылаются, если настроен хотя бы один канал; о задаче со временем
+	// напоминаем за RemindBefore минут. Каналы ведут к контактам владельца сервера,
+	// поэтому напоминания приходят только о задачах общего пользователя
+	if notifiers := notify.FromConfig(cfg); len(notifiers) > 0 {
+		reminders := &jobs.Reminders{
+			Store:     instrumented,
+			Notifiers: notifiers,
+			UserID:    auth.SharedUserID,
+			Lang:      cfg.Lang,
+			Before:    time.Duration(cfg.RemindBefore) * time.Minute,
+			Interval:  time.Minute,
+		}
+		start(reminders.Run)
 	}
 
-	// Инициализация подключения к базе данных
-	dbConn, err := sql.Open("sqlite", dbPath)
+	// События о задачах передаются клиентам через /api/events и подписчикам через исходящие вебхуки.
+	// Вебхуки сохраняются в очередь обработчиками запросов, а отправляются отдельной горутиной
+	allowed, err := webhooks.ParseNetworks(cfg.WebhookAllow)
 	if err != nil {
-		log.Fatalf("Не удалось подключиться к базе данных: %v", err)
+		return err
 	}
-	defer dbConn.Close() // Закрываем подключение при завершении программы
+	bus := events.NewBus()
+	dispatcher := &webhooks.Dispatcher{Store: instrumented, Guard: webhooks.Guard{Allow: allowed}}
+	start(dispatcher.Run)
 
-	// Инициализируем обработчики с передачей подключения к базе данных
-	handler := handlers.NewHandler(dbConn)
+	// Инициализируем обработчики с передачей хранилища
+	handler := handlers.NewHandler(instrumented, authenticator, bus)
+	handler.Registration = cfg.Registration
+	handler.Webhooks = dispatcher
 
 	// Устанавливаем маршруты
-	http.HandleFunc("/api/task", handler.HandleTask)          // Для действий с задачами
-	http.HandleFunc("/api/nextdate", handlers.HandleDate)     // Для расчёта следующей даты
-	http.HandleFunc("/api/tasks", handler.HandleTaskList)     // Для списка задач
-	http.HandleFunc("/api/task/done", handler.HandleTaskDone) // Для завершения задачи
+	handle("/api/signin", handler.HandleSignIn)                                          // Для входа по паролю
+	handle("/api/register", handler.HandleRegister)                                      // Для регистрации пользователя
+	handle("/api/login", handler.HandleLogin)                                            // Для входа пользователя
+	handle("/api/task", handler.RequireAuth(handler.HandleTask))                         // Для действий с задачами
+	handle("/api/nextdate", handlers.HandleDate)                                         // Для расчёта следующей даты
+	handle("/api/tasks", handler.RequireAuth(handler.HandleTaskList))                    // Для списка задач
+	handle("/api/agenda", handler.RequireAuth(handler.HandleAgenda))                     // Для повестки с повторениями задач
+	handle("/api/task/done", handler.RequireAuth(handler.HandleTaskDone))                // Для завершения задачи
+	handle("/api/task/undo", handler.RequireAuth(handler.HandleTaskUndo))                // Для отмены выполнения задачи
+	handle("/api/history", handler.RequireAuth(handler.HandleHistory))                   // Для истории выполнения задач
+	handle("/api/trash", handler.RequireAuth(handler.HandleTrash))                       // Для списка удалённых задач
+	handle("/api/trash/restore", handler.RequireAuth(handler.HandleTrashRestore))        // Для восстановления задачи из корзины
+	handle("/api/tags", handler.RequireAuth(handler.HandleTags))                         // Для списка меток
+	handle("/api/projects", handler.RequireAuth(handler.HandleProjects))                 // Для списка проектов
+	handle("/api/calendar.ics", handler.RequireFeedAuth(handler.HandleCalendar))         // Для подписки из календарей
+	handle("/api/calendar/token", handler.RequireAuth(handler.HandleFeedToken))          // Для токена подписки на календарь
+	handle("/api/tasks/import", handler.RequireAuth(handler.HandleCalendarImport))       // Для импорта задач из файлов .ics
+	handle("/api/events", handler.RequireAuth(handler.HandleEvents))                     // Для получения изменений задач в реальном времени
+	handle("/api/webhooks", handler.RequireAuth(handler.HandleWebhooks))                 // Для подписок на события
+	handle("/api/webhooks/dead", handler.RequireAuth(handler.HandleDeadDeliveries))      // Для недоставленных событий
+	handle("/api/webhooks/dead/retry", handler.RequireAuth(handler.HandleDeliveryRetry)) // Для повторной доставки события
+	handle("/healthz", handlers.HandleHealth)                                            // Для проверки, что сервер запущен
+	handle("/readyz", handler.HandleReady)                                               // Для проверки готовности (доступна база данных)
+	handle("/metrics", metrics.Handler().ServeHTTP)                                      // Для метрик Prometheus
 
-	// Получаем порт из переменной окружения (Задача со звёздочкой)
-	port := os.Getenv("TODO_PORT")
-	if port == "" {
-		port = "7540" // Порт по умолчанию
+	server := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Port),
+		Handler:           logging.Middleware(i18n.Middleware(cfg.Lang, mux)),
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
 	}
+	// Потоки событий не завершаются сами, их нужно закрыть, чтобы Shutdown не ждал до таймаута
+	server.RegisterOnShutdown(handler.CloseStreams)
 
-	// Запускаем сервер
-	log.Printf("Starting server on :%s\n", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatalf("Error starting server: %v\n", err)
+	// Запускаем сервер, по HTTPS - если заданы сертификат и ключ
+	serveErr := make(chan error, 1)
+	go func() {
+		if cfg.TLS() {
+			slog.Info("starting HTTPS server", "addr", server.Addr)
+			serveErr <- server.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+		} else {
+			slog.Info("starting server", "addr", server.Addr)
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	// Работаем до SIGINT или SIGTERM, затем даём запросам и фоновым задачам
+	// ShutdownTimeout секунд на завершение; база закрывается отложенным store.Close
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("error starting server: %w", err)
+	case <-signals.Done():
+	}
+	slog.Info("shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("requests did not finish before shutdown", "error", err)
+	}
+
+	stopJobs()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		slog.Warn("background jobs did not finish before shutdown")
+	}
+	slog.Info("server stopped")
+	return nil
+}
+
+// runCheckConfig проверяет настройки и выводит итоговые значения без паролей
+func runCheckConfig(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return errors.New(strings.ReplaceAll(err.Error(), "\n", "; "))
 	}
+	return nil
 }
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/config"
+	"go_final_project/db"
+)
+
+// syncBuffer буфер журнала, в который пишут несколько горутин
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// freePort возвращает свободный TCP-порт
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// TestGracefulShutdown проверяет остановку по SIGTERM: начатый запрос завершается,
+// фоновые задачи останавливаются, база данных закрывается
+func TestGracefulShutdown(t *testing.T) {
+	// Пока тест подписан на SIGTERM, сигнал не завершит процесс, даже если придёт
+	// раньше, чем runServe начнёт его ждать
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var logs syncBuffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	var store *db.SQLStore
+	openStore = func(databaseURL, dbFile string) (*db.SQLStore, error) {
+		var err error
+		store, err = db.Open(databaseURL, dbFile)
+		return store, err
+	}
+	defer func() { openStore = db.Open }()
+
+	cfg := config.Default()
+	cfg.Port = freePort(t)
+	cfg.DBFile = filepath.Join(t.TempDir(), "scheduler.db")
+	cfg.ShutdownTimeout = 10
+	base := fmt.Sprintf("http://127.0.0.1:%d", cfg.Port)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- runServe(cfg, nil) }()
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(base + "/healthz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// Импорт календаря, тело которого ещё передаётся в момент сигнала
+	date := time.Now().AddDate(0, 0, 1).Format("20060102")
+	body, upload := io.Pipe()
+	type result struct {
+		status int
+		body   map[string]any
+		err    error
+	}
+	imported := make(chan result, 1)
+	go func() {
+		resp, err := http.Post(base+"/api/tasks/import", "text/calendar", body)
+		if err != nil {
+			imported <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		var ret map[string]any
+		json.NewDecoder(resp.Body).Decode(&ret)
+		imported <- result{status: resp.StatusCode, body: ret}
+	}()
+	_, err := io.WriteString(upload, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n")
+	require.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(syscall.SIGTERM))
+	require.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "shutting down server")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Новые соединения уже не принимаются
+	require.Eventually(t, func() bool {
+		_, err := http.Get(base + "/healthz")
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = io.WriteString(upload, "BEGIN:VEVENT\r\nUID:shutdown@example.com\r\nSUMMARY:Дописанная задача\r\n"+
+		"DTSTART;VALUE=DATE:"+date+"\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")
+	require.NoError(t, err)
+	require.NoError(t, upload.Close())
+
+	select {
+	case res := <-imported:
+		require.NoError(t, res.err)
+		assert.Equal(t, http.StatusOK, res.status)
+		assert.EqualValues(t, 1, res.body["created"])
+	case <-time.After(10 * time.Second):
+		t.Fatal("начатый запрос не завершился")
+	}
+
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(15 * time.Second):
+		t.Fatal("сервер не остановился")
+	}
+
+	output := logs.String()
+	assert.Contains(t, output, "server stopped")
+	assert.NotContains(t, output, "did not finish")
+	require.NotNil(t, store)
+	assert.Error(t, store.Ping(context.Background()), "база данных должна быть закрыта")
+}
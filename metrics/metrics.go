@@ -0,0 +1,131 @@
+// Package metrics собирает метрики сервера в формате Prometheus.
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"go_final_project/constants"
+	"go_final_project/db"
+)
+
+// Registry реестр метрик сервера. Отдельный реестр вместо глобального,
+// чтобы в /metrics попадало только то, что регистрирует сервер.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "todo_http_requests_total",
+		Help: "Число обработанных HTTP-запросов.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "todo_http_request_duration_seconds",
+		Help:    "Длительность обработки HTTP-запросов.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+
+	dbDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "todo_db_query_duration_seconds",
+		Help:    "Длительность операций с базой данных.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+
+	dbErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "todo_db_errors_total",
+		Help: "Число операций с базой данных, завершившихся ошибкой.",
+	}, []string{"operation"})
+
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "todo_job_runs_total",
+		Help: "Результаты фоновых задач: очистки корзины, напоминаний и доставки вебхуков.",
+	}, []string{"job", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, dbDuration, dbErrors, jobRuns,
+	)
+}
+
+// Handler отдаёт метрики из Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Instrument считает запросы к маршруту route и время их обработки.
+// Маршрут передаётся шаблоном, а не путём запроса, чтобы число рядов не росло
+// от произвольных URL.
+func Instrument(route string, next http.Handler) http.Handler {
+	labels := prometheus.Labels{"route": route}
+	return promhttp.InstrumentHandlerCounter(httpRequests.MustCurryWith(labels),
+		promhttp.InstrumentHandlerDuration(httpDuration.MustCurryWith(labels), next))
+}
+
+// Результаты фоновых задач для JobRun
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+	OutcomeRetry   = "retry" // доставка не удалась и будет повторена
+	OutcomeDead    = "dead"  // доставка не удалась окончательно
+)
+
+// JobRun отмечает результат outcome фоновой задачи job.
+func JobRun(job, outcome string) {
+	jobRuns.WithLabelValues(job, outcome).Inc()
+}
+
+// observeQuery записывает длительность операции с базой данных,
+// начатой в start, и ошибку, если она есть.
+func observeQuery(operation string, start time.Time, err error) {
+	dbDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		dbErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// countTimeout ограничивает подсчёт задач при каждом чтении метрик
+const countTimeout = 5 * time.Second
+
+// taskCounts отдаёт число задач по состояниям, подсчитанное в момент чтения метрик.
+type taskCounts struct {
+	store db.StatsStore
+	desc  *prometheus.Desc
+}
+
+// RegisterTaskCounts добавляет в Registry метрику todo_tasks с числом задач
+// всех пользователей по состояниям (см. db.TaskState*).
+func RegisterTaskCounts(store db.StatsStore) {
+	Registry.MustRegister(&taskCounts{
+		store: store,
+		desc:  prometheus.NewDesc("todo_tasks", "Число задач по состояниям.", []string{"state"}, nil),
+	})
+}
+
+func (c *taskCounts) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect при ошибке базы данных пропускает метрику, чтобы остальные метрики
+// были доступны и тогда, когда база недоступна.
+func (c *taskCounts) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	counts, err := c.store.CountTasks(ctx, time.Now().Format(constants.DateFormat))
+	if err != nil {
+		slog.Error("failed to count tasks", "error", err)
+		return
+	}
+	for state, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), state)
+	}
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/db"
+	"go_final_project/models"
+)
+
+// fakeStore хранилище, в котором реализованы только нужные тестам методы
+type fakeStore struct {
+	db.Store
+	err    error
+	counts map[string]int64
+}
+
+func (s *fakeStore) GetTaskByID(ctx context.Context, userID, id int64) (*models.Task, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Task{ID: "1"}, nil
+}
+
+func (s *fakeStore) CountTasks(ctx context.Context, today string) (map[string]int64, error) {
+	return s.counts, s.err
+}
+
+func TestInstrument(t *testing.T) {
+	handler := Instrument("/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("/test", "post", "418"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/test?id=1", nil))
+	assert.Equal(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues("/test", "post", "418")))
+}
+
+func TestInstrumentStore(t *testing.T) {
+	store := &fakeStore{}
+	instrumented := InstrumentStore(store)
+
+	errorsBefore := testutil.ToFloat64(dbErrors.WithLabelValues("GetTaskByID"))
+	_, err := instrumented.GetTaskByID(context.Background(), 1, 1)
+	require.NoError(t, err)
+
+	// Ненайденная задача не считается ошибкой базы данных
+	store.err = db.ErrTaskNotFound
+	_, err = instrumented.GetTaskByID(context.Background(), 1, 1)
+	assert.ErrorIs(t, err, db.ErrTaskNotFound)
+	assert.Equal(t, errorsBefore, testutil.ToFloat64(dbErrors.WithLabelValues("GetTaskByID")))
+
+	store.err = errors.New("disk I/O error")
+	_, err = instrumented.GetTaskByID(context.Background(), 1, 1)
+	assert.Error(t, err)
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(dbErrors.WithLabelValues("GetTaskByID")))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(dbDuration, "todo_db_query_duration_seconds"))
+}
+
+func TestTaskCounts(t *testing.T) {
+	store := &fakeStore{counts: map[string]int64{db.TaskStateActive: 3, db.TaskStateOverdue: 1, db.TaskStateTrash: 0}}
+	collector := &taskCounts{
+		store: store,
+		desc:  prometheus.NewDesc("todo_tasks", "Число задач по состояниям.", []string{"state"}, nil),
+	}
+
+	expected := `
+# HELP todo_tasks Число задач по состояниям.
+# TYPE todo_tasks gauge
+todo_tasks{state="active"} 3
+todo_tasks{state="overdue"} 1
+todo_tasks{state="trash"} 0
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+
+	// Без базы данных метрика пропускается
+	store.err = errors.New("database is closed")
+	assert.Equal(t, 0, testutil.CollectAndCount(collector))
+}
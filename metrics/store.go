@@ -0,0 +1,206 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_final_project/db"
+	"go_final_project/models"
+)
+
+// InstrumentStore возвращает хранилище, которое замеряет длительность каждой
+// операции store в todo_db_query_duration_seconds с именем метода в метке operation.
+func InstrumentStore(store db.Store) db.Store {
+	return &instrumentedStore{store: store}
+}
+
+type instrumentedStore struct {
+	store db.Store
+}
+
+// observe вызывается отложенно: err читается после возврата из метода.
+// Отсутствие записи или конфликт версий - обычные ответы хранилища, ошибками базы данных они не считаются.
+func observe(operation string, start time.Time, err *error) {
+	failed := *err
+	if errors.Is(failed, db.ErrTaskNotFound) || errors.Is(failed, db.ErrUserNotFound) ||
+		errors.Is(failed, db.ErrUserExists) || errors.Is(failed, db.ErrNothingToUndo) ||
+		errors.Is(failed, db.ErrVersionConflict) || errors.Is(failed, db.ErrFeedTokenNotFound) {
+		failed = nil
+	}
+	observeQuery(operation, start, failed)
+}
+
+func (s *instrumentedStore) AddTask(ctx context.Context, userID int64, task models.Task) (_ int64, err error) {
+	defer observe("AddTask", time.Now(), &err)
+	return s.store.AddTask(ctx, userID, task)
+}
+
+func (s *instrumentedStore) AddTasks(ctx context.Context, userID int64, tasks []models.Task) (_ []int64, err error) {
+	defer observe("AddTasks", time.Now(), &err)
+	return s.store.AddTasks(ctx, userID, tasks)
+}
+
+func (s *instrumentedStore) GetTaskByID(ctx context.Context, userID, id int64) (_ *models.Task, err error) {
+	defer observe("GetTaskByID", time.Now(), &err)
+	return s.store.GetTaskByID(ctx, userID, id)
+}
+
+func (s *instrumentedStore) UpdateTask(ctx context.Context, userID int64, task models.Task) (_ int64, err error) {
+	defer observe("UpdateTask", time.Now(), &err)
+	return s.store.UpdateTask(ctx, userID, task)
+}
+
+func (s *instrumentedStore) DeleteTask(ctx context.Context, userID, id int64) (_ int64, err error) {
+	defer observe("DeleteTask", time.Now(), &err)
+	return s.store.DeleteTask(ctx, userID, id)
+}
+
+func (s *instrumentedStore) ListTasks(ctx context.Context, userID int64, filter db.TaskFilter) (_ []models.Task, err error) {
+	defer observe("ListTasks", time.Now(), &err)
+	return s.store.ListTasks(ctx, userID, filter)
+}
+
+func (s *instrumentedStore) ListTags(ctx context.Context, userID int64) (_ []string, err error) {
+	defer observe("ListTags", time.Now(), &err)
+	return s.store.ListTags(ctx, userID)
+}
+
+func (s *instrumentedStore) ListProjects(ctx context.Context, userID int64) (_ []string, err error) {
+	defer observe("ListProjects", time.Now(), &err)
+	return s.store.ListProjects(ctx, userID)
+}
+
+func (s *instrumentedStore) ListTrash(ctx context.Context, userID int64) (_ []models.Task, err error) {
+	defer observe("ListTrash", time.Now(), &err)
+	return s.store.ListTrash(ctx, userID)
+}
+
+func (s *instrumentedStore) RestoreTask(ctx context.Context, userID, id int64) (_ int64, err error) {
+	defer observe("RestoreTask", time.Now(), &err)
+	return s.store.RestoreTask(ctx, userID, id)
+}
+
+func (s *instrumentedStore) PurgeTrash(ctx context.Context, before time.Time) (_ int64, err error) {
+	defer observe("PurgeTrash", time.Now(), &err)
+	return s.store.PurgeTrash(ctx, before)
+}
+
+func (s *instrumentedStore) CompleteTask(ctx context.Context, userID int64, task models.Task, nextDate string) (_ *models.Completion, err error) {
+	defer observe("CompleteTask", time.Now(), &err)
+	return s.store.CompleteTask(ctx, userID, task, nextDate)
+}
+
+func (s *instrumentedStore) ListCompletions(ctx context.Context, userID int64, filter db.CompletionFilter) (_ []models.Completion, err error) {
+	defer observe("ListCompletions", time.Now(), &err)
+	return s.store.ListCompletions(ctx, userID, filter)
+}
+
+func (s *instrumentedStore) UndoCompletion(ctx context.Context, userID, taskID int64) (_ *models.Task, err error) {
+	defer observe("UndoCompletion", time.Now(), &err)
+	return s.store.UndoCompletion(ctx, userID, taskID)
+}
+
+func (s *instrumentedStore) PendingReminders(ctx context.Context, userID int64, date, channel string) (_ []models.Task, err error) {
+	defer observe("PendingReminders", time.Now(), &err)
+	return s.store.PendingReminders(ctx, userID, date, channel)
+}
+
+func (s *instrumentedStore) MarkReminderSent(ctx context.Context, taskID int64, date, channel string) (err error) {
+	defer observe("MarkReminderSent", time.Now(), &err)
+	return s.store.MarkReminderSent(ctx, taskID, date, channel)
+}
+
+func (s *instrumentedStore) AddWebhook(ctx context.Context, userID int64, webhook models.Webhook) (_ int64, err error) {
+	defer observe("AddWebhook", time.Now(), &err)
+	return s.store.AddWebhook(ctx, userID, webhook)
+}
+
+func (s *instrumentedStore) ListWebhooks(ctx context.Context, userID int64) (_ []models.Webhook, err error) {
+	defer observe("ListWebhooks", time.Now(), &err)
+	return s.store.ListWebhooks(ctx, userID)
+}
+
+func (s *instrumentedStore) DeleteWebhook(ctx context.Context, userID, id int64) (_ int64, err error) {
+	defer observe("DeleteWebhook", time.Now(), &err)
+	return s.store.DeleteWebhook(ctx, userID, id)
+}
+
+func (s *instrumentedStore) EnqueueDeliveries(ctx context.Context, userID int64, event string, payload []byte) (_ int64, err error) {
+	defer observe("EnqueueDeliveries", time.Now(), &err)
+	return s.store.EnqueueDeliveries(ctx, userID, event, payload)
+}
+
+func (s *instrumentedStore) DueDeliveries(ctx context.Context, now time.Time, limit int) (_ []models.WebhookDelivery, err error) {
+	defer observe("DueDeliveries", time.Now(), &err)
+	return s.store.DueDeliveries(ctx, now, limit)
+}
+
+func (s *instrumentedStore) UpdateDelivery(ctx context.Context, delivery models.WebhookDelivery) (err error) {
+	defer observe("UpdateDelivery", time.Now(), &err)
+	return s.store.UpdateDelivery(ctx, delivery)
+}
+
+func (s *instrumentedStore) ListDeadDeliveries(ctx context.Context, userID int64) (_ []models.WebhookDelivery, err error) {
+	defer observe("ListDeadDeliveries", time.Now(), &err)
+	return s.store.ListDeadDeliveries(ctx, userID)
+}
+
+func (s *instrumentedStore) RetryDelivery(ctx context.Context, userID, id int64) (_ int64, err error) {
+	defer observe("RetryDelivery", time.Now(), &err)
+	return s.store.RetryDelivery(ctx, userID, id)
+}
+
+func (s *instrumentedStore) PurgeDeliveries(ctx context.Context, before time.Time) (_ int64, err error) {
+	defer observe("PurgeDeliveries", time.Now(), &err)
+	return s.store.PurgeDeliveries(ctx, before)
+}
+
+func (s *instrumentedStore) CountTasks(ctx context.Context, today string) (_ map[string]int64, err error) {
+	defer observe("CountTasks", time.Now(), &err)
+	return s.store.CountTasks(ctx, today)
+}
+
+func (s *instrumentedStore) AddUser(ctx context.Context, login, passwordHash string) (_ int64, err error) {
+	defer observe("AddUser", time.Now(), &err)
+	return s.store.AddUser(ctx, login, passwordHash)
+}
+
+func (s *instrumentedStore) GetUserByLogin(ctx context.Context, login string) (_ *models.User, err error) {
+	defer observe("GetUserByLogin", time.Now(), &err)
+	return s.store.GetUserByLogin(ctx, login)
+}
+
+func (s *instrumentedStore) GetUserByID(ctx context.Context, id int64) (_ *models.User, err error) {
+	defer observe("GetUserByID", time.Now(), &err)
+	return s.store.GetUserByID(ctx, id)
+}
+
+func (s *instrumentedStore) TokenSecret(ctx context.Context) (_ string, err error) {
+	defer observe("TokenSecret", time.Now(), &err)
+	return s.store.TokenSecret(ctx)
+}
+
+func (s *instrumentedStore) ResetFeedToken(ctx context.Context, userID int64) (_ string, err error) {
+	defer observe("ResetFeedToken", time.Now(), &err)
+	return s.store.ResetFeedToken(ctx, userID)
+}
+
+func (s *instrumentedStore) RevokeFeedToken(ctx context.Context, userID int64) (err error) {
+	defer observe("RevokeFeedToken", time.Now(), &err)
+	return s.store.RevokeFeedToken(ctx, userID)
+}
+
+func (s *instrumentedStore) FeedTokenUser(ctx context.Context, token string) (_ int64, err error) {
+	defer observe("FeedTokenUser", time.Now(), &err)
+	return s.store.FeedTokenUser(ctx, token)
+}
+
+func (s *instrumentedStore) Ping(ctx context.Context) (err error) {
+	defer observe("Ping", time.Now(), &err)
+	return s.store.Ping(ctx)
+}
+
+func (s *instrumentedStore) Close() error {
+	return s.store.Close()
+}
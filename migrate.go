@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"go_final_project/config"
+	"go_final_project/db"
+)
+
+// runMigrate выполняет подкоманду migrate:
+//
+//	migrate up [N]    применить миграции (до версии N или все)
+//	migrate down [N]  откатить N последних миграций (по умолчанию одну)
+//	migrate status    показать состояние миграций
+func runMigrate(cfg *config.Config, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	var n int
+	if len(args) > 1 {
+		var err error
+		n, err = strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid argument: %s", args[1])
+		}
+	}
+
+	store, err := db.Open(cfg.DatabaseURL, cfg.DBFile)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch command {
+	case "up":
+		return store.MigrateUp(n)
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		return store.MigrateDown(n)
+	case "status":
+		states, err := store.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			appliedAt := "pending"
+			if state.AppliedAt != "" {
+				appliedAt = "applied " + state.AppliedAt
+			}
+			fmt.Fprintf(os.Stdout, "%04d %-20s %s\n", state.Version, state.Name, appliedAt)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q (expected up, down or status)", command)
+	}
+}
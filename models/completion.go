@@ -0,0 +1,17 @@
+package models
+
+// Completion описывает запись о выполнении задачи из таблицы completions
+type Completion struct {
+	ID          string   `json:"id"`
+	TaskID      string   `json:"task_id"`
+	Title       string   `json:"title"`
+	Comment     string   `json:"comment"`
+	Repeat      string   `json:"repeat"`
+	Date        string   `json:"date"`      // дата, на которую была назначена задача
+	NextDate    string   `json:"next_date"` // дата следующего повторения, пустая для разовой задачи
+	Priority    int      `json:"priority,omitempty"`
+	Time        string   `json:"time,omitempty"`
+	Project     string   `json:"project,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	CompletedAt string   `json:"completed_at"`
+}
@@ -7,4 +7,15 @@ type Task struct {
 	Title   string `json:"title"`
 	Comment string `json:"comment"`
 	Repeat  string `json:"repeat"`
+
+	RepeatText string `json:"repeat_text,omitempty"` // описание правила повторения на языке запроса, заполняется только в ответах API
+
+	Priority  int      `json:"priority,omitempty"` // 0 - не задан, 1 - низкий, 2 - средний, 3 - высокий
+	Time      string   `json:"time,omitempty"`     // время в формате HH:MM
+	Project   string   `json:"project,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	DeletedAt string   `json:"deleted_at,omitempty"` // время удаления, заполняется только для задач из корзины
+
+	Version   int64  `json:"version,omitempty,string"` // растёт при каждом изменении задачи, 0 - не проверять версию при записи
+	UpdatedAt string `json:"updated_at,omitempty"`     // время последнего изменения
 }
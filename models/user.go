@@ -0,0 +1,8 @@
+package models
+
+// User описывает учётную запись из таблицы users
+type User struct {
+	ID           int64
+	Login        string
+	PasswordHash string
+}
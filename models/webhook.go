@@ -0,0 +1,34 @@
+package models
+
+import "encoding/json"
+
+// Webhook описывает подписку на события из таблицы webhooks
+type Webhook struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events,omitempty"` // пустой список - все события
+	Secret    string   `json:"secret,omitempty"` // ключ подписи, возвращается только при создании
+	CreatedAt string   `json:"created_at"`
+}
+
+// Статусы доставки события
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery описывает доставку события подписчику из таблицы webhook_deliveries
+type WebhookDelivery struct {
+	ID            string          `json:"id"`
+	WebhookID     string          `json:"webhook_id"`
+	URL           string          `json:"url"`
+	Secret        string          `json:"-"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt string          `json:"next_attempt_at"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     string          `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"strings"
+
+	"go_final_project/config"
+)
+
+// FromConfig создаёт каналы, заданные в настройках: почта (SMTPAddr),
+// webhook (NotifyWebhookURL) и бот (TelegramToken).
+func FromConfig(c *config.Config) []Notifier {
+	var notifiers []Notifier
+
+	if c.SMTPAddr != "" {
+		var to []string
+		for _, address := range strings.Split(c.SMTPTo, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				to = append(to, address)
+			}
+		}
+		notifiers = append(notifiers, &SMTP{
+			Addr:     c.SMTPAddr,
+			From:     c.SMTPFrom,
+			To:       to,
+			Username: c.SMTPUser,
+			Password: c.SMTPPassword,
+		})
+	}
+
+	if c.NotifyWebhookURL != "" {
+		notifiers = append(notifiers, &Webhook{URL: c.NotifyWebhookURL})
+	}
+
+	if c.TelegramToken != "" {
+		notifiers = append(notifiers, &Telegram{
+			APIURL: c.TelegramAPIURL,
+			Token:  c.TelegramToken,
+			ChatID: c.TelegramChatID,
+		})
+	}
+
+	return notifiers
+}
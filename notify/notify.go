@@ -0,0 +1,39 @@
+// Package notify отправляет напоминания о задачах по разным каналам.
+package notify
+
+import (
+	"context"
+
+	"go_final_project/i18n"
+	"go_final_project/models"
+)
+
+// Message напоминание о задаче
+type Message struct {
+	UserID  int64
+	Task    models.Task
+	Subject string
+	Text    string
+}
+
+// Notifier канал доставки напоминаний.
+// Name используется как ключ состояния доставки, поэтому должно быть постоянным.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// NewMessage формирует напоминание о задаче на сегодня на языке lang
+func NewMessage(lang string, userID int64, task models.Task) Message {
+	subject := i18n.T(lang, i18n.ReminderSubject, task.Title)
+
+	text := i18n.T(lang, i18n.ReminderToday, task.Title)
+	if task.Time != "" {
+		text = i18n.T(lang, i18n.ReminderTodayAt, task.Title, task.Time)
+	}
+	if task.Comment != "" {
+		text += "\n\n" + task.Comment
+	}
+
+	return Message{UserID: userID, Task: task, Subject: subject, Text: text}
+}
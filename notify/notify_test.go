@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/config"
+	"go_final_project/models"
+)
+
+var testTask = models.Task{ID: "7", Date: "20240115", Title: "Созвон", Comment: "Ссылка в чате", Time: "10:30"}
+
+func TestNewMessage(t *testing.T) {
+	msg := NewMessage("ru", 3, testTask)
+	assert.Equal(t, "Напоминание: Созвон", msg.Subject)
+	assert.Equal(t, "Созвон — сегодня в 10:30\n\nСсылка в чате", msg.Text)
+
+	msg = NewMessage("en", 3, models.Task{Title: "Call"})
+	assert.Equal(t, "Reminder: Call", msg.Subject)
+	assert.Equal(t, "Call — today", msg.Text)
+}
+
+func TestWebhook(t *testing.T) {
+	var got webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	webhook := &Webhook{URL: server.URL}
+	require.NoError(t, webhook.Notify(context.Background(), NewMessage("ru", 3, testTask)))
+	assert.Equal(t, "task.reminder", got.Event)
+	assert.Equal(t, "3", got.UserID)
+	assert.Equal(t, "7", got.Task.ID)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	assert.Error(t, (&Webhook{URL: failing.URL}).Notify(context.Background(), NewMessage("ru", 3, testTask)))
+}
+
+func TestTelegram(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botsecret/sendMessage" {
+			w.Write([]byte(`{"ok": false, "description": "Not Found"}`))
+			return
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	bot := &Telegram{APIURL: server.URL, Token: "secret", ChatID: "42"}
+	require.NoError(t, bot.Notify(context.Background(), NewMessage("ru", 3, testTask)))
+	assert.Equal(t, "42", got["chat_id"])
+	assert.Contains(t, got["text"], "Созвон")
+
+	bot.Token = "wrong"
+	assert.ErrorContains(t, bot.Notify(context.Background(), NewMessage("ru", 3, testTask)), "Not Found")
+
+	// Токен не попадает в текст ошибки
+	unreachable := &Telegram{APIURL: "http://127.0.0.1:1", Token: "secret", ChatID: "42"}
+	err := unreachable.Notify(context.Background(), NewMessage("ru", 3, testTask))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+// smtpStandIn принимает одно письмо по SMTP и возвращает его текст в канал
+func smtpStandIn(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	mail := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					mail <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().String(), mail
+}
+
+func TestSMTP(t *testing.T) {
+	addr, mail := smtpStandIn(t)
+
+	sender := &SMTP{Addr: addr, From: "todo@example.com", To: []string{"me@example.com"}}
+	require.NoError(t, sender.Notify(context.Background(), NewMessage("ru", 3, testTask)))
+
+	body := <-mail
+	assert.Contains(t, body, "To: me@example.com\r\n")
+	assert.Contains(t, body, "Subject: =?utf-8?q?")
+	assert.Contains(t, body, "Созвон — сегодня в 10:30\r\n\r\nСсылка в чате")
+}
+
+func TestSMTPTimeout(t *testing.T) {
+	// Сервер принимает соединение, но не отвечает
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	sender := &SMTP{Addr: listener.Addr().String(), From: "todo@example.com", To: []string{"me@example.com"},
+		Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	assert.Error(t, sender.Notify(context.Background(), NewMessage("ru", 3, testTask)))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestFromConfig(t *testing.T) {
+	assert.Empty(t, FromConfig(config.Default()))
+
+	c := config.Default()
+	c.SMTPAddr = "mail.example.com:25"
+	c.SMTPFrom = "todo@example.com"
+	c.SMTPTo = "me@example.com, , boss@example.com"
+	c.NotifyWebhookURL = "https://example.com/remind"
+	c.TelegramToken = "bot-token"
+	c.TelegramChatID = "42"
+	notifiers := FromConfig(c)
+	require.Len(t, notifiers, 3)
+
+	mail := notifiers[0].(*SMTP)
+	assert.Equal(t, []string{"me@example.com", "boss@example.com"}, mail.To)
+	assert.Equal(t, "webhook", notifiers[1].Name())
+	bot := notifiers[2].(*Telegram)
+	assert.Equal(t, "42", bot.ChatID)
+	assert.Empty(t, bot.APIURL)
+}
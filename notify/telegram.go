@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultTelegramAPI адрес Bot API по умолчанию
+const DefaultTelegramAPI = "https://api.telegram.org"
+
+// Telegram отправляет напоминания через Bot API (метод sendMessage).
+// APIURL можно заменить на совместимый сервер или локальную заглушку.
+type Telegram struct {
+	APIURL string // пустая строка - DefaultTelegramAPI
+	Token  string
+	ChatID string
+	Client *http.Client // nil - клиент с таймаутом requestTimeout
+}
+
+// Name возвращает имя канала
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+// Notify отправляет сообщение в чат
+func (t *Telegram) Notify(ctx context.Context, msg Message) error {
+	apiURL := t.APIURL
+	if apiURL == "" {
+		apiURL = DefaultTelegramAPI
+	}
+
+	var resp struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	err := postJSON(ctx, t.Client, strings.TrimSuffix(apiURL, "/")+"/bot"+t.Token+"/sendMessage", map[string]any{
+		"chat_id": t.ChatID,
+		"text":    msg.Text,
+	}, &resp)
+	// Токен входит в адрес запроса, поэтому не должен попасть в текст ошибки
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = apiURL + "/bot***/sendMessage"
+	}
+	if err != nil {
+		return err
+	}
+	if !resp.OK {
+		return errors.New("telegram: " + resp.Description)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_final_project/models"
+)
+
+// requestTimeout ограничивает время запроса к внешнему сервису
+const requestTimeout = 10 * time.Second
+
+var defaultClient = &http.Client{Timeout: requestTimeout}
+
+// Webhook отправляет напоминания POST-запросом с телом JSON
+type Webhook struct {
+	URL    string
+	Client *http.Client // nil - клиент с таймаутом requestTimeout
+}
+
+// webhookPayload тело запроса с напоминанием
+type webhookPayload struct {
+	Event   string      `json:"event"`
+	UserID  string      `json:"user_id"`
+	Subject string      `json:"subject"`
+	Text    string      `json:"text"`
+	Task    models.Task `json:"task"`
+}
+
+// Name возвращает имя канала
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Notify отправляет напоминание на URL и ждёт ответа 2xx
+func (w *Webhook) Notify(ctx context.Context, msg Message) error {
+	return postJSON(ctx, w.Client, w.URL, webhookPayload{
+		Event:   "task.reminder",
+		UserID:  strconv.FormatInt(msg.UserID, 10),
+		Subject: msg.Subject,
+		Text:    msg.Text,
+		Task:    msg.Task,
+	}, nil)
+}
+
+// postJSON отправляет value в формате JSON и при out != nil разбирает ответ в out
+func postJSON(ctx context.Context, client *http.Client, url string, value, out any) error {
+	if client == nil {
+		client = defaultClient
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
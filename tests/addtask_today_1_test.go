@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddTaskToday проверяет, что повторяющаяся задача на сегодня не переносится
+// на следующее повторение, а задача из прошлого переносится
+func TestAddTaskToday(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+	weekday := int(now.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+
+	tbl := []struct {
+		date, repeat string
+		want         func(date string) bool
+	}{
+		{today, "d 1", func(date string) bool { return date == today }},
+		{today, "d 7", func(date string) bool { return date == today }},
+		{today, "y", func(date string) bool { return date == today }},
+		{today, "w " + strconv.Itoa(weekday), func(date string) bool { return date == today }},
+		{today, "m " + strconv.Itoa(now.Day()), func(date string) bool { return date == today }},
+		{now.AddDate(0, 0, -3).Format(`20060102`), "d 7", func(date string) bool { return date > today }},
+	}
+	for _, v := range tbl {
+		ret, err := postJSON("api/task", map[string]any{
+			"date":   v.date,
+			"title":  "Задача на сегодня",
+			"repeat": v.repeat,
+		}, http.MethodPost)
+		require.NoError(t, err)
+		require.NotEmpty(t, ret["id"], "%s %s", v.date, v.repeat)
+		id := fmt.Sprint(ret["id"])
+
+		task, err := postJSON("api/task?id="+id, nil, http.MethodGet)
+		assert.NoError(t, err)
+		date := fmt.Sprint(task["date"])
+		assert.True(t, v.want(date), "%s %s: %s", v.date, v.repeat, date)
+	}
+}
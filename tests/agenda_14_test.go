@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgenda(t *testing.T) {
+	start := time.Now().AddDate(0, 0, 60)
+	day := func(n int) string {
+		return start.AddDate(0, 0, n).Format(`20060102`)
+	}
+
+	repeating := addTask(t, task{
+		date:   day(0),
+		title:  "Полив",
+		repeat: "d 3",
+	})
+	defer postJSON("api/task?id="+repeating, nil, http.MethodDelete)
+	oneOff := addTask(t, task{
+		date:  day(5),
+		title: "Встреча",
+	})
+	defer postJSON("api/task?id="+oneOff, nil, http.MethodDelete)
+
+	body, err := requestJSON("api/agenda?from="+day(2)+"&to="+day(9), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var resp struct {
+		From  string              `json:"from"`
+		To    string              `json:"to"`
+		Items []map[string]string `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &resp))
+	assert.Equal(t, day(2), resp.From)
+	assert.Equal(t, day(9), resp.To)
+
+	var got [][2]string
+	for _, item := range resp.Items {
+		if item["task_id"] == repeating || item["task_id"] == oneOff {
+			got = append(got, [2]string{item["date"], item["task_id"]})
+		}
+	}
+	assert.Equal(t, [][2]string{
+		{day(3), repeating},
+		{day(5), oneOff},
+		{day(6), repeating},
+		{day(9), repeating},
+	}, got)
+
+	ret, err := postJSON("api/agenda?from="+day(9)+"&to="+day(2), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
@@ -11,11 +11,18 @@ import (
 )
 
 type Task struct {
-	ID      int64  `db:"id"`
-	Date    string `db:"date"`
-	Title   string `db:"title"`
-	Comment string `db:"comment"`
-	Repeat  string `db:"repeat"`
+	ID        int64  `db:"id"`
+	Date      string `db:"date"`
+	Title     string `db:"title"`
+	Comment   string `db:"comment"`
+	Repeat    string `db:"repeat"`
+	UserID    int64  `db:"user_id"`
+	DeletedAt string `db:"deleted_at"`
+	ProjectID int64  `db:"project_id"`
+	Priority  int    `db:"priority"`
+	Time      string `db:"time"`
+	Version   int64  `db:"version"`
+	UpdatedAt string `db:"updated_at"`
 }
 
 func count(db *sqlx.DB) (int, error) {
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiError тело ответа с ошибкой
+type apiError struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+	Field string `json:"field"`
+}
+
+// requestStatus выполняет запрос с телом body и возвращает код ответа и ошибку из тела
+func requestStatus(t *testing.T, method, apipath, body string) (int, apiError) {
+	t.Helper()
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var ret apiError
+	json.NewDecoder(resp.Body).Decode(&ret)
+	return resp.StatusCode, ret
+}
+
+func TestErrors(t *testing.T) {
+	tbl := []struct {
+		method, path, body string
+		status             int
+		code, field        string
+	}{
+		{http.MethodGet, "api/task", "", http.StatusBadRequest, "invalid_parameter", "id"},
+		{http.MethodGet, "api/task?id=abc", "", http.StatusBadRequest, "invalid_parameter", "id"},
+		{http.MethodGet, "api/task?id=999999999", "", http.StatusNotFound, "not_found", ""},
+		{http.MethodPost, "api/task/done?id=999999999", "", http.StatusNotFound, "not_found", ""},
+		{http.MethodDelete, "api/task?id=999999999", "", http.StatusNotFound, "not_found", ""},
+		{http.MethodPut, "api/task", `{"id":"999999999","title":"Нет такой задачи","priority":0,"time":"","project":"","tags":[]}`,
+			http.StatusNotFound, "not_found", ""},
+		{http.MethodPost, "api/task", `{"title":`, http.StatusBadRequest, "bad_request", ""},
+		{http.MethodPost, "api/task", `{"date":"20240230","title":"Задача"}`, http.StatusUnprocessableEntity, "validation_failed", "date"},
+		{http.MethodPost, "api/task", `{"date":"20240201"}`, http.StatusUnprocessableEntity, "validation_failed", "title"},
+		{http.MethodPost, "api/task", `{"title":"Задача","repeat":"w 8"}`, http.StatusUnprocessableEntity, "validation_failed", "repeat"},
+		{http.MethodPost, "api/task", `{"title":"Задача","priority":4}`, http.StatusUnprocessableEntity, "validation_failed", "priority"},
+		{http.MethodPost, "api/task", `{"title":"Задача","tags":["a,b"]}`, http.StatusUnprocessableEntity, "validation_failed", "tags"},
+		{http.MethodPatch, "api/task", "", http.StatusMethodNotAllowed, "method_not_allowed", ""},
+		{http.MethodGet, "api/tasks?limit=0", "", http.StatusBadRequest, "invalid_parameter", "limit"},
+		{http.MethodGet, "api/nextdate?now=bad&date=20240101&repeat=d+1", "", http.StatusBadRequest, "invalid_parameter", "now"},
+		{http.MethodPost, "api/trash/restore?id=999999999", "", http.StatusNotFound, "not_found", ""},
+	}
+	for _, v := range tbl {
+		status, ret := requestStatus(t, v.method, v.path, v.body)
+		assert.Equal(t, v.status, status, "%s %s", v.method, v.path)
+		assert.Equal(t, v.code, ret.Code, "%s %s", v.method, v.path)
+		assert.Equal(t, v.field, ret.Field, "%s %s", v.method, v.path)
+		assert.NotEmpty(t, ret.Error, "%s %s", v.method, v.path)
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamEvent struct {
+	name string
+	data map[string]any
+}
+
+// readEvents разбирает поток Server-Sent Events и передаёт события в канал
+func readEvents(resp *http.Response, out chan<- streamEvent) {
+	defer close(out)
+
+	var event streamEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event.name != "" {
+				out <- event
+			}
+			event = streamEvent{}
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.data)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, getURL("api/events"), nil)
+	require.NoError(t, err)
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	stream := make(chan streamEvent, 10)
+	go readEvents(resp, stream)
+
+	wait := func(name, id string) streamEvent {
+		t.Helper()
+		for {
+			select {
+			case event, ok := <-stream:
+				require.True(t, ok, "поток событий закрыт")
+				task, _ := event.data["task"].(map[string]any)
+				if event.name == name && task["id"] == id {
+					return event
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("событие %s для задачи %s не получено", name, id)
+			}
+		}
+	}
+
+	id := addTask(t, task{title: "Проверить поток событий"})
+	event := wait("task.created", id)
+	assert.Equal(t, "Проверить поток событий", event.data["task"].(map[string]any)["title"])
+
+	_, err = postJSON("api/task", map[string]any{"id": id, "title": "Поток событий проверен"}, http.MethodPut)
+	assert.NoError(t, err)
+	event = wait("task.updated", id)
+	assert.Equal(t, "Поток событий проверен", event.data["task"].(map[string]any)["title"])
+
+	_, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	wait("task.done", id)
+
+	id = addTask(t, task{title: "Удалить из потока"})
+	wait("task.created", id)
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	wait("task.deleted", id)
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	for _, path := range []string{"healthz", "readyz"} {
+		resp, err := http.Get(getURL(path))
+		require.NoError(t, err)
+		var body map[string]string
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, "ok", body["status"], path)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	// Запрос к API, который должен попасть в метрики
+	_, err := requestJSON("api/tasks", nil, http.MethodGet)
+	require.NoError(t, err)
+
+	resp, err := http.Get(getURL("metrics"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	metrics := string(body)
+	assert.Contains(t, metrics, `todo_http_requests_total{code="200",method="get",route="/api/tasks"}`)
+	assert.Contains(t, metrics, `todo_http_request_duration_seconds_bucket{code="200",method="get",route="/api/tasks"`)
+	assert.Contains(t, metrics, `todo_db_query_duration_seconds_count{operation="ListTasks"}`)
+	for _, state := range []string{"active", "overdue", "trash"} {
+		assert.Contains(t, metrics, `todo_tasks{state="`+state+`"}`)
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getHistory(t *testing.T, query string) []map[string]string {
+	body, err := requestJSON("api/history?"+query, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]map[string]string
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m["completions"]
+}
+
+func TestHistory(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+	id := addTask(t, task{
+		date:    today,
+		title:   "Полить цветы",
+		comment: "В кабинете",
+	})
+
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+
+	history := getHistory(t, "id="+id+"&from="+today+"&to="+today)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, id, history[0]["task_id"])
+		assert.Equal(t, "Полить цветы", history[0]["title"])
+		assert.Equal(t, today, history[0]["date"])
+		assert.Empty(t, history[0]["next_date"])
+	}
+	assert.Empty(t, getHistory(t, "id="+id+"&to="+now.AddDate(0, 0, -1).Format(`20060102`)))
+
+	ret, err = postJSON("api/task/undo?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, id, ret["id"])
+	assert.Equal(t, today, ret["date"])
+	assert.Equal(t, "В кабинете", ret["comment"])
+	assert.Empty(t, getHistory(t, "id="+id))
+
+	ret, err = postJSON("api/task/undo?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+
+	id = addTask(t, task{
+		date:   today,
+		title:  "Проверить почту",
+		repeat: "d 2",
+	})
+	defer postJSON("api/task?id="+id, nil, http.MethodDelete)
+
+	for i := 0; i < 2; i++ {
+		ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+	}
+	history = getHistory(t, "id="+id)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, now.AddDate(0, 0, 4).Format(`20060102`), history[0]["next_date"])
+	}
+
+	ret, err = postJSON("api/task/undo?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 2).Format(`20060102`), ret["date"])
+	assert.Len(t, getHistory(t, "id="+id), 1)
+}
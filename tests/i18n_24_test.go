@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getLocalized выполняет GET-запрос с заголовком Accept-Language lang
+// и разбирает тело ответа в body
+func getLocalized(t *testing.T, apipath, lang string, body any) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, getURL(apipath), nil)
+	require.NoError(t, err)
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(body))
+	return resp
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	tbl := []struct {
+		lang, want string
+	}{
+		{"", "Задача не найдена"},
+		{"en-US,en;q=0.9", "Task not found"},
+		{"de, ru;q=0.5", "Задача не найдена"},
+	}
+	for _, v := range tbl {
+		var ret apiError
+		resp := getLocalized(t, "api/task?id=999999999", v.lang, &ret)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "not_found", ret.Code)
+		assert.Equal(t, v.want, ret.Error, v.lang)
+	}
+
+	var ret apiError
+	resp := getLocalized(t, "api/nextdate?now=20240101&date=20240101&repeat=d+500", "en", &ret)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "repeat", ret.Field)
+	assert.Equal(t, "Invalid repeat rule", ret.Error)
+	assert.Equal(t, "en", resp.Header.Get("Content-Language"))
+}
+
+func TestRepeatText(t *testing.T) {
+	id := addTask(t, task{
+		date:   "20240129",
+		title:  "Полить цветы",
+		repeat: "d 7",
+	})
+	defer requestJSON("api/task?id="+id, nil, http.MethodDelete)
+
+	var ru map[string]any
+	getLocalized(t, "api/task?id="+id, "ru", &ru)
+	assert.Equal(t, "каждые 7 дней", ru["repeat_text"])
+
+	var en map[string]any
+	getLocalized(t, "api/task?id="+id, "en", &en)
+	assert.Equal(t, "every 7 days", en["repeat_text"])
+
+	var list struct {
+		Tasks []map[string]any `json:"tasks"`
+	}
+	getLocalized(t, "api/tasks", "en", &list)
+	for _, task := range list.Tasks {
+		if task["id"] == id {
+			assert.Equal(t, "every 7 days", task["repeat_text"])
+		}
+	}
+}
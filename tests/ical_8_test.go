@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	id := addTask(t, task{
+		date:    time.Now().Format(`20060102`),
+		title:   "Планёрка",
+		comment: "Зал 2, второй этаж",
+		repeat:  "w 1,3",
+	})
+	defer postJSON("api/task?id="+id, nil, http.MethodDelete)
+
+	body, err := requestJSON("api/calendar.ics", nil, http.MethodGet)
+	assert.NoError(t, err)
+	ics := strings.ReplaceAll(string(body), "\r\n ", "")
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	assert.Contains(t, ics, "UID:task-"+id+"@todo-scheduler\r\n")
+	assert.Contains(t, ics, "SUMMARY:Планёрка\r\n")
+	assert.Contains(t, ics, `DESCRIPTION:Зал 2\, второй этаж`+"\r\n")
+	assert.Contains(t, ics, "RRULE:FREQ=WEEKLY;BYDAY=MO,WE\r\n")
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+}
+
+func postCalendar(t *testing.T, ics string) map[string]any {
+	req, err := http.NewRequest(http.MethodPost, getURL("api/tasks/import"), strings.NewReader(ics))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "text/calendar")
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var m map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+	return m
+}
+
+func TestCalendarImport(t *testing.T) {
+	now := time.Now()
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:weekly@test",
+		"DTSTART;VALUE=DATE:" + now.AddDate(0, 0, -14).Format(`20060102`),
+		"SUMMARY:Планёрка",
+		"DESCRIPTION:Зал 2\\, второй",
+		"  этаж",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:todo@test",
+		"DUE;VALUE=DATE:" + now.AddDate(0, 0, 3).Format(`20060102`),
+		"SUMMARY:Сдать отчёт",
+		"END:VTODO",
+		"BEGIN:VEVENT",
+		"UID:count@test",
+		"DTSTART;VALUE=DATE:" + now.Format(`20060102`),
+		"SUMMARY:Ограниченный повтор",
+		"RRULE:FREQ=DAILY;COUNT=3",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:past@test",
+		"DTSTART;VALUE=DATE:20000101",
+		"SUMMARY:Давнее событие",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	m := postCalendar(t, ics)
+	assert.Equal(t, float64(2), m["created"])
+	assert.Equal(t, float64(1), m["skipped"])
+	assert.Equal(t, float64(1), m["failed"])
+
+	items, ok := m["items"].([]any)
+	if !assert.True(t, ok) || !assert.Len(t, items, 4) {
+		return
+	}
+	statuses := []string{"created", "created", "error", "skipped"}
+	for i, v := range items {
+		item := v.(map[string]any)
+		assert.Equal(t, statuses[i], item["status"])
+		if item["status"] != "created" {
+			assert.NotEmpty(t, item["error"])
+			continue
+		}
+		id := item["id"].(string)
+		defer postJSON("api/task?id="+id, nil, http.MethodDelete)
+	}
+
+	weekly := items[0].(map[string]any)
+	body, err := requestJSON("api/task?id="+weekly["id"].(string), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var task map[string]string
+	assert.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "Планёрка", task["title"])
+	assert.Equal(t, "Зал 2, второй этаж", task["comment"])
+	assert.Equal(t, "w 1,3", task["repeat"])
+	assert.GreaterOrEqual(t, task["date"], now.Format(`20060102`))
+
+	todo := items[1].(map[string]any)
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), todo["date"])
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type taskPage struct {
+	Tasks      []map[string]any `json:"tasks"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+func getPage(t *testing.T, query string) taskPage {
+	body, err := requestJSON("api/tasks?"+query, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var page taskPage
+	assert.NoError(t, json.Unmarshal(body, &page))
+	return page
+}
+
+func TestPagination(t *testing.T) {
+	date := time.Now().AddDate(0, 0, 50)
+	search := "search=" + date.Format(`02.01.2006`)
+
+	var ids []string
+	for _, v := range []map[string]any{
+		{"title": "1", "time": "08:00"},
+		{"title": "2", "time": "08:00"},
+		{"title": "3", "time": "12:00", "priority": 2},
+		{"title": "4", "priority": 3},
+		{"title": "5"},
+		{"title": "6"},
+		{"title": "7"},
+	} {
+		v["date"] = date.Format(`20060102`)
+		ret, err := postJSON("api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		ids = append(ids, ret["id"].(string))
+	}
+	defer func() {
+		for _, id := range ids {
+			postJSON("api/task?id="+id, nil, http.MethodDelete)
+		}
+	}()
+
+	full := getPage(t, search)
+	assert.Empty(t, full.NextCursor)
+	var want []any
+	for _, task := range full.Tasks {
+		want = append(want, task["id"])
+	}
+	assert.Len(t, want, 7)
+
+	var got []any
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		query := search + "&limit=2"
+		if cursor != "" {
+			query += "&cursor=" + cursor
+		}
+		page := getPage(t, query)
+		for _, task := range page.Tasks {
+			got = append(got, task["id"])
+		}
+
+		if pages == 0 {
+			// Задача, добавленная перед курсором, не сдвигает следующие страницы
+			ret, err := postJSON("api/task", map[string]any{
+				"date":  date.Format(`20060102`),
+				"title": "0",
+				"time":  "07:00",
+			}, http.MethodPost)
+			assert.NoError(t, err)
+			ids = append(ids, ret["id"].(string))
+		}
+
+		cursor = page.NextCursor
+		if cursor == "" {
+			break
+		}
+		assert.Len(t, page.Tasks, 2)
+	}
+	assert.Equal(t, want, got)
+
+	ret, err := postJSON("api/tasks?cursor=garbage", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityAndTime(t *testing.T) {
+	date := time.Now().AddDate(0, 0, 40)
+
+	var ids []string
+	for _, v := range []map[string]any{
+		{"title": "Без времени, обычная"},
+		{"title": "Без времени, важная", "priority": 3},
+		{"title": "Вечер", "time": "18:30"},
+		{"title": "Утро, низкий", "time": "09:00", "priority": 1},
+		{"title": "Утро, высокий", "time": "09:00", "priority": 3},
+	} {
+		v["date"] = date.Format(`20060102`)
+		ret, err := postJSON("api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["id"])
+		ids = append(ids, ret["id"].(string))
+	}
+	defer func() {
+		for _, id := range ids {
+			postJSON("api/task?id="+id, nil, http.MethodDelete)
+		}
+	}()
+
+	for _, v := range []map[string]any{
+		{"title": "Плохое время", "time": "25:00"},
+		{"title": "Плохое время", "time": "9:05"},
+		{"title": "Плохой приоритет", "priority": 4},
+	} {
+		ret, err := postJSON("api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], v)
+	}
+
+	body, err := requestJSON("api/tasks?search="+date.Format(`02.01.2006`), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+
+	var titles []string
+	for _, task := range m["tasks"] {
+		titles = append(titles, task["title"].(string))
+	}
+	assert.Equal(t, []string{
+		"Утро, высокий",
+		"Утро, низкий",
+		"Вечер",
+		"Без времени, важная",
+		"Без времени, обычная",
+	}, titles)
+
+	// Поля, не переданные в PUT, сохраняются
+	ret, err := postJSON("api/task", map[string]any{
+		"id":    ids[4],
+		"date":  date.Format(`20060102`),
+		"title": "Утро, высокий приоритет",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	body, err = requestJSON("api/task?id="+ids[4], nil, http.MethodGet)
+	assert.NoError(t, err)
+	var task map[string]any
+	assert.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "09:00", task["time"])
+	assert.Equal(t, float64(3), task["priority"])
+}
@@ -2,6 +2,7 @@ package tests
 
 var Port = 7540
 var DBFile = "../scheduler.db"
-var FullNextDate = false
-var Search = false
+var FullNextDate = true
+var Search = true
 var Token = ``
+var WebhookLoopback = true // сервер запущен с TODO_WEBHOOK_ALLOW=127.0.0.1
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type labeledTask struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Project string   `json:"project"`
+	Tags    []string `json:"tags"`
+}
+
+func getLabeledTasks(t *testing.T, query string) []labeledTask {
+	body, err := requestJSON("api/tasks?"+query, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]labeledTask
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m["tasks"]
+}
+
+func taskTitles(tasks []labeledTask) []string {
+	var titles []string
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func TestTags(t *testing.T) {
+	var ids []string
+	for _, v := range []map[string]any{
+		{"title": "Ревью кода", "project": "Бэкенд", "tags": []string{"работа", "срочно"}},
+		{"title": "Обновить зависимости", "project": "Бэкенд", "tags": []string{" работа ", "работа"}},
+		{"title": "Купить продукты", "tags": []string{"дом", "срочно"}},
+	} {
+		ret, err := postJSON("api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["id"])
+		ids = append(ids, ret["id"].(string))
+	}
+	defer func() {
+		for _, id := range ids {
+			postJSON("api/task?id="+id, nil, http.MethodDelete)
+		}
+	}()
+
+	ret, err := postJSON("api/task", map[string]any{"title": "Плохая метка", "tags": []string{"a,b"}}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	assert.ElementsMatch(t, []string{"Ревью кода", "Обновить зависимости"}, taskTitles(getLabeledTasks(t, "project=Бэкенд")))
+	assert.ElementsMatch(t, []string{"Ревью кода", "Купить продукты"}, taskTitles(getLabeledTasks(t, "tag=срочно")))
+	assert.Equal(t, []string{"Ревью кода"}, taskTitles(getLabeledTasks(t, "tag=работа,срочно")))
+	assert.Equal(t, []string{"Купить продукты"}, taskTitles(getLabeledTasks(t, "tag=срочно&tag=дом")))
+	assert.Empty(t, getLabeledTasks(t, "project="+url.QueryEscape("Нет такого")))
+
+	body, err := requestJSON("api/task?id="+ids[1], nil, http.MethodGet)
+	assert.NoError(t, err)
+	var task labeledTask
+	assert.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "Бэкенд", task.Project)
+	assert.Equal(t, []string{"работа"}, task.Tags)
+
+	// Без полей project и tags прежние значения сохраняются
+	ret, err = postJSON("api/task", map[string]any{
+		"id":    ids[1],
+		"date":  "",
+		"title": "Обновить зависимости до последних версий",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	body, err = requestJSON("api/task?id="+ids[1], nil, http.MethodGet)
+	assert.NoError(t, err)
+	task = labeledTask{}
+	assert.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "Бэкенд", task.Project)
+	assert.Equal(t, []string{"работа"}, task.Tags)
+
+	ret, err = postJSON("api/task", map[string]any{
+		"id":      ids[1],
+		"date":    "",
+		"title":   "Обновить зависимости",
+		"project": "",
+		"tags":    []string{"техдолг"},
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Equal(t, []string{"Ревью кода"}, taskTitles(getLabeledTasks(t, "project=Бэкенд")))
+	assert.Equal(t, []string{"Обновить зависимости"}, taskTitles(getLabeledTasks(t, "tag=техдолг")))
+
+	body, err = requestJSON("api/tags", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var tags map[string][]string
+	assert.NoError(t, json.Unmarshal(body, &tags))
+	assert.Subset(t, tags["tags"], []string{"дом", "работа", "срочно", "техдолг"})
+}
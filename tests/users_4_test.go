@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// asUser выполняет запрос с токеном пользователя token и возвращает код ответа и тело
+func asUser(t *testing.T, token, method, apipath string, body any) (int, map[string]any) {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewReader(data))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var ret map[string]any
+	json.NewDecoder(resp.Body).Decode(&ret)
+	return resp.StatusCode, ret
+}
+
+// registerUser регистрирует пользователя с уникальным логином и возвращает его токен
+func registerUser(t *testing.T, name string) string {
+	t.Helper()
+	ret, err := postJSON("api/register", map[string]any{
+		"login":    fmt.Sprintf("%s-%d", name, time.Now().UnixNano()),
+		"password": "long-password",
+	}, http.MethodPost)
+	require.NoError(t, err)
+	token, _ := ret["token"].(string)
+	require.NotEmpty(t, token, "регистрация %s: %v", name, ret["error"])
+	return token
+}
+
+// TestUserIsolation проверяет, что пользователь не видит и не меняет чужие задачи
+func TestUserIsolation(t *testing.T) {
+	owner := registerUser(t, "owner")
+	other := registerUser(t, "other")
+
+	status, ret := asUser(t, owner, http.MethodPost, "api/task", map[string]any{
+		"date":   time.Now().Format(`20060102`),
+		"title":  "Личная задача",
+		"repeat": "d 1",
+	})
+	require.Equal(t, http.StatusOK, status)
+	id := fmt.Sprint(ret["id"])
+	task := "api/task?id=" + id
+
+	status, _ = asUser(t, other, http.MethodGet, task, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = asUser(t, other, http.MethodPut, "api/task", map[string]any{
+		"id": id, "date": time.Now().Format(`20060102`), "title": "Чужое изменение",
+	})
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = asUser(t, other, http.MethodPost, "api/task/done?id="+id, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = asUser(t, other, http.MethodDelete, task, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, ret = asUser(t, other, http.MethodGet, "api/tasks", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, ret["tasks"])
+
+	// Владелец видит задачу без изменений и может её выполнить
+	status, ret = asUser(t, owner, http.MethodGet, task, nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Личная задача", ret["title"])
+	status, _ = asUser(t, owner, http.MethodPost, "api/task/done?id="+id, nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	// История и отмена выполнения тоже разделены
+	status, _ = asUser(t, other, http.MethodPost, "api/task/undo?id="+id, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, ret = asUser(t, other, http.MethodGet, "api/history", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, ret["completions"])
+	status, ret = asUser(t, owner, http.MethodGet, "api/history?id="+id, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, ret["completions"])
+
+	// Задачу из корзины восстанавливает только владелец
+	status, _ = asUser(t, owner, http.MethodDelete, task, nil)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = asUser(t, other, http.MethodPost, "api/trash/restore?id="+id, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, ret = asUser(t, other, http.MethodGet, "api/trash", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, ret["tasks"])
+	status, _ = asUser(t, owner, http.MethodPost, "api/trash/restore?id="+id, nil)
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conditionalRequest выполняет запрос с заголовком If-Match ifMatch (пусто - без заголовка)
+// и возвращает ответ с уже прочитанным телом
+func conditionalRequest(t *testing.T, method, apipath, ifMatch string, body any) (*http.Response, []byte) {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewReader(data))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	return resp, buf.Bytes()
+}
+
+// getVersioned возвращает задачу и её ETag
+func getVersioned(t *testing.T, id string) (map[string]any, string) {
+	t.Helper()
+	resp, body := conditionalRequest(t, http.MethodGet, "api/task?id="+id, "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var task map[string]any
+	require.NoError(t, json.Unmarshal(body, &task))
+	return task, resp.Header.Get("ETag")
+}
+
+func TestTaskVersion(t *testing.T) {
+	id := addTask(t, task{
+		date:   "20240129",
+		title:  "Проверить версию",
+		repeat: "d 5",
+	})
+	defer requestJSON("api/task?id="+id, nil, http.MethodDelete)
+
+	task, etag := getVersioned(t, id)
+	assert.Equal(t, `"1"`, etag)
+	assert.Equal(t, "1", task["version"])
+	assert.NotEmpty(t, task["updated_at"])
+
+	edit := func(title, version string) map[string]any {
+		values := map[string]any{"id": id, "date": "20240129", "title": title, "repeat": "d 5"}
+		if version != "" {
+			values["version"] = version
+		}
+		return values
+	}
+
+	// Первое изменение проходит и возвращает новый ETag
+	resp, _ := conditionalRequest(t, http.MethodPut, "api/task", etag, edit("Первое изменение", ""))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	// Второй клиент пишет по старой версии
+	var ret apiError
+	resp, body := conditionalRequest(t, http.MethodPut, "api/task", etag, edit("Второе изменение", ""))
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	require.NoError(t, json.Unmarshal(body, &ret))
+	assert.Equal(t, "precondition_failed", ret.Code)
+
+	resp, body = conditionalRequest(t, http.MethodPut, "api/task", "", edit("Второе изменение", "1"))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	require.NoError(t, json.Unmarshal(body, &ret))
+	assert.Equal(t, "conflict", ret.Code)
+
+	task, etag = getVersioned(t, id)
+	assert.Equal(t, "Первое изменение", task["title"])
+	assert.Equal(t, `"2"`, etag)
+
+	// Без версии задача обновляется как раньше, ответ тоже содержит новый ETag
+	resp, _ = conditionalRequest(t, http.MethodPut, "api/task", "", edit("Без версии", ""))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+	_, etag = getVersioned(t, id)
+	assert.Equal(t, `"3"`, etag)
+
+	resp, _ = conditionalRequest(t, http.MethodPut, "api/task", "abc", edit("Без версии", ""))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Выполнение тоже проверяет If-Match
+	resp, _ = conditionalRequest(t, http.MethodPost, "api/task/done?id="+id, `"2"`, nil)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	resp, _ = conditionalRequest(t, http.MethodPost, "api/task/done?id="+id, etag, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"4"`, resp.Header.Get("ETag"))
+	task, etag = getVersioned(t, id)
+	assert.Equal(t, `"4"`, etag)
+	assert.NotEqual(t, "20240129", task["date"])
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookCall struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+func TestWebhooks(t *testing.T) {
+	calls := make(chan webhookCall, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls <- webhookCall{
+			event:     r.Header.Get("X-Todo-Event"),
+			signature: r.Header.Get("X-Todo-Signature"),
+			body:      body,
+		}
+	}))
+	defer receiver.Close()
+
+	ret, err := postJSON("api/webhooks", map[string]any{"url": "ftp://example.com"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	// Внутренние адреса запрещены, чтобы через подписку нельзя было обращаться к службам сервера
+	for _, target := range []string{"http://10.0.0.1/hook", "http://169.254.169.254/latest", "http://[::1]:22/x"} {
+		ret, err = postJSON("api/webhooks", map[string]any{"url": target}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], target)
+	}
+	if !WebhookLoopback {
+		ret, err = postJSON("api/webhooks", map[string]any{"url": "http://127.0.0.1:22/x"}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"])
+		return
+	}
+	ret, err = postJSON("api/webhooks", map[string]any{
+		"url":    receiver.URL,
+		"events": []string{"task.unknown"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	ret, err = postJSON("api/webhooks", map[string]any{
+		"url":    receiver.URL,
+		"events": []string{"task.created", "task.done"},
+	}, http.MethodPost)
+	require.NoError(t, err)
+	require.Empty(t, ret["error"])
+	webhookID, _ := ret["id"].(string)
+	secret, _ := ret["secret"].(string)
+	require.NotEmpty(t, webhookID)
+	require.NotEmpty(t, secret)
+	defer postJSON("api/webhooks?id="+webhookID, nil, http.MethodDelete)
+
+	body, err := requestJSON("api/webhooks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var list struct {
+		Webhooks []map[string]any `json:"webhooks"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &list))
+	var found bool
+	for _, webhook := range list.Webhooks {
+		if webhook["id"] == webhookID {
+			found = true
+			assert.Nil(t, webhook["secret"])
+		}
+	}
+	assert.True(t, found)
+
+	// События других тестов могут дойти до очереди позже, поэтому ждём событие нужной задачи
+	wait := func(event, id string) map[string]any {
+		t.Helper()
+		for {
+			select {
+			case call := <-calls:
+				if call.event != event {
+					continue
+				}
+				mac := hmac.New(sha256.New, []byte(secret))
+				mac.Write(call.body)
+				assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), call.signature)
+
+				var payload map[string]any
+				assert.NoError(t, json.Unmarshal(call.body, &payload))
+				assert.Equal(t, event, payload["event"])
+				if task, _ := payload["task"].(map[string]any); task["id"] != id {
+					continue
+				}
+				return payload
+			case <-time.After(5 * time.Second):
+				t.Fatalf("событие %s не доставлено", event)
+				return nil
+			}
+		}
+	}
+
+	id := addTask(t, task{title: "Позвонить в банк"})
+	payload := wait("task.created", id)
+	task, _ := payload["task"].(map[string]any)
+	assert.Equal(t, id, task["id"])
+	assert.Equal(t, "Позвонить в банк", task["title"])
+
+	// На изменение подписки нет, событие task.updated не отправляется
+	_, err = postJSON("api/task", map[string]any{"id": id, "title": "Позвонить в банк до обеда"}, http.MethodPut)
+	assert.NoError(t, err)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	payload = wait("task.done", id)
+	task, _ = payload["task"].(map[string]any)
+	assert.Equal(t, "Позвонить в банк до обеда", task["title"])
+
+	ret, err = postJSON("api/webhooks?id="+webhookID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/webhooks?id="+webhookID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	body, err = requestJSON("api/webhooks/dead", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var dead map[string]any
+	assert.NoError(t, json.Unmarshal(body, &dead))
+	assert.Contains(t, dead, "deliveries")
+}
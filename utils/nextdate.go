@@ -4,56 +4,213 @@ import (
 	"errors"
 	"fmt"
 	"go_final_project/constants"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// NextDate вычисляет следующую дату для задачи на основе правил повторения.
-// Звёздочки (правила w и m) не реализовал (пока).
-func NextDate(now time.Time, date string, repeat string) (string, error) {
-	// Парсим начальную дату
-	startDate, err := time.Parse(constants.DateFormat, date)
-	if err != nil {
-		return "", fmt.Errorf("invalid date format: %s", date)
-	}
+// maxSearchDays ограничивает поиск даты для правил w и m,
+// чтобы правила вроде "m 31 2" не приводили к бесконечному циклу.
+const maxSearchDays = 366 * 10
+
+// Ошибки разбора даты и правила повторения. Конкретная причина добавляется
+// к ним через %w, по ним обработчики выбирают сообщение для пользователя.
+var (
+	ErrInvalidDate   = errors.New("invalid date")
+	ErrInvalidRepeat = errors.New("invalid repeat rule")
+)
+
+// RepeatRule описывает разобранное правило повторения задачи.
+type RepeatRule struct {
+	Kind      string // "d", "y", "w" или "m"
+	Days      int    // интервал в днях для правила d
+	Weekdays  []int  // дни недели 1-7 (понедельник - воскресенье) для правила w
+	MonthDays []int  // дни месяца 1-31, -1 и -2 (последний и предпоследний) для правила m
+	Months    []int  // месяцы 1-12 для правила m, пустой список означает любой месяц
+}
 
-	// Если повторение пустое, возвращаем ошибку
+// ParseRepeat разбирает и проверяет правило повторения.
+func ParseRepeat(repeat string) (RepeatRule, error) {
 	if repeat == "" {
-		return "", errors.New("empty repeat rule")
+		return RepeatRule{}, fmt.Errorf("%w: empty rule", ErrInvalidRepeat)
 	}
 
-	// Разбираем правила
 	ruleParts := strings.Split(repeat, " ")
-	switch ruleParts[0] {
+	rule := RepeatRule{Kind: ruleParts[0]}
+	switch rule.Kind {
 	case "d":
 		// Правило "d <число>"
 		if len(ruleParts) != 2 {
-			return "", errors.New("invalid repeat format for 'd'")
+			return RepeatRule{}, fmt.Errorf("%w: invalid format for 'd'", ErrInvalidRepeat)
 		}
 		days, err := strconv.Atoi(ruleParts[1])
 		if err != nil || days <= 0 || days > 400 {
-			return "", errors.New("invalid days in repeat rule")
+			return RepeatRule{}, fmt.Errorf("%w: invalid days", ErrInvalidRepeat)
 		}
+		rule.Days = days
+
+	case "y":
+		// Правило "y"
+
+	case "w":
+		// Правило "w <дни недели через запятую>"
+		if len(ruleParts) != 2 {
+			return RepeatRule{}, fmt.Errorf("%w: invalid format for 'w'", ErrInvalidRepeat)
+		}
+		weekdays, err := parseList(ruleParts[1], func(n int) bool { return n >= 1 && n <= 7 })
+		if err != nil {
+			return RepeatRule{}, fmt.Errorf("%w: invalid weekday", ErrInvalidRepeat)
+		}
+		rule.Weekdays = weekdays
+
+	case "m":
+		// Правило "m <дни месяца через запятую> [месяцы через запятую]"
+		if len(ruleParts) != 2 && len(ruleParts) != 3 {
+			return RepeatRule{}, fmt.Errorf("%w: invalid format for 'm'", ErrInvalidRepeat)
+		}
+		monthDays, err := parseList(ruleParts[1], func(n int) bool {
+			return (n >= 1 && n <= 31) || n == -1 || n == -2
+		})
+		if err != nil {
+			return RepeatRule{}, fmt.Errorf("%w: invalid day of month", ErrInvalidRepeat)
+		}
+		rule.MonthDays = monthDays
+
+		if len(ruleParts) == 3 {
+			months, err := parseList(ruleParts[2], func(n int) bool { return n >= 1 && n <= 12 })
+			if err != nil {
+				return RepeatRule{}, fmt.Errorf("%w: invalid month", ErrInvalidRepeat)
+			}
+			rule.Months = months
+		}
+
+	default:
+		return RepeatRule{}, fmt.Errorf("%w: unsupported rule", ErrInvalidRepeat)
+	}
+
+	return rule, nil
+}
+
+// NextDate вычисляет следующую дату для задачи на основе правил повторения.
+func NextDate(now time.Time, date string, repeat string) (string, error) {
+	// Парсим начальную дату
+	startDate, err := time.Parse(constants.DateFormat, date)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidDate, date)
+	}
 
-		// Вычисляем следующую дату
-		for nextDate := startDate.AddDate(0, 0, days); ; nextDate = nextDate.AddDate(0, 0, days) {
+	rule, err := ParseRepeat(repeat)
+	if err != nil {
+		return "", err
+	}
+
+	nextDate, err := rule.Next(now, startDate)
+	if err != nil {
+		return "", err
+	}
+	return nextDate.Format(constants.DateFormat), nil
+}
+
+// Next возвращает первую дату по правилу, которая больше now.
+// Для правил d и y даты отсчитываются от start, для w и m ищется
+// ближайший подходящий день после большей из дат now и start.
+func (r RepeatRule) Next(now, start time.Time) (time.Time, error) {
+	switch r.Kind {
+	case "d":
+		for nextDate := start.AddDate(0, 0, r.Days); ; nextDate = nextDate.AddDate(0, 0, r.Days) {
 			if nextDate.After(now) {
-				return nextDate.Format(constants.DateFormat), nil
+				return nextDate, nil
 			}
 		}
 
 	case "y":
-		// Правило "y"
-		for nextDate := startDate.AddDate(1, 0, 0); ; nextDate = nextDate.AddDate(1, 0, 0) {
+		for nextDate := start.AddDate(1, 0, 0); ; nextDate = nextDate.AddDate(1, 0, 0) {
 			if nextDate.After(now) {
-				return nextDate.Format(constants.DateFormat), nil
+				return nextDate, nil
 			}
 		}
 
-	default:
-		return "", errors.New("invalid or unsupported repeat rule")
+	case "w", "m":
+		from := start
+		if now.After(from) {
+			from = now
+		}
+		from = NormalizeDate(from)
+		for i := 1; i <= maxSearchDays; i++ {
+			nextDate := from.AddDate(0, 0, i)
+			if r.matches(nextDate) {
+				return nextDate, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("%w: no matching date", ErrInvalidRepeat)
+	}
+
+	return time.Time{}, fmt.Errorf("%w: unsupported rule", ErrInvalidRepeat)
+}
+
+// Occurrences возвращает даты задачи с первой датой start в интервале [from, to].
+// Каждая следующая дата вычисляется так же, как при выполнении задачи в день
+// предыдущей: Next(date, date).
+func (r RepeatRule) Occurrences(start, from, to time.Time) []time.Time {
+	var dates []time.Time
+	date := start
+	if date.Before(from) {
+		next, err := r.Next(from.AddDate(0, 0, -1), start)
+		if err != nil {
+			return nil
+		}
+		date = next
+	}
+
+	for !date.After(to) {
+		dates = append(dates, date)
+		next, err := r.Next(date, date)
+		if err != nil {
+			break
+		}
+		date = next
+	}
+	return dates
+}
+
+// matches проверяет, подходит ли день под правило w или m.
+func (r RepeatRule) matches(date time.Time) bool {
+	if r.Kind == "w" {
+		weekday := int(date.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		return slices.Contains(r.Weekdays, weekday)
+	}
+
+	if len(r.Months) > 0 && !slices.Contains(r.Months, int(date.Month())) {
+		return false
+	}
+	lastDay := date.AddDate(0, 1, -date.Day()).Day()
+	for _, day := range r.MonthDays {
+		if day < 0 {
+			day = lastDay + day + 1
+		}
+		if day == date.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// parseList разбирает список чисел через запятую и проверяет каждое значение.
+func parseList(s string, valid func(int) bool) ([]int, error) {
+	var list []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(part)
+		if err != nil || !valid(n) {
+			return nil, fmt.Errorf("invalid value: %s", part)
+		}
+		list = append(list, n)
 	}
+	slices.Sort(list)
+	return list, nil
 }
 
 // NormalizeDate возвращает дату без времени (только год, месяц и день).
@@ -0,0 +1,99 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// ErrPrivateAddress возвращается, если адрес подписки ведёт во внутреннюю сеть
+var ErrPrivateAddress = errors.New("webhook address is not public")
+
+// blocked подсети, которые не считаются внутренними по netip, но не должны быть доступны
+// подписчикам: "этот" сегмент 0.0.0.0/8 и адреса операторского NAT
+var blocked = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// Guard запрещает доставку на внутренние адреса: loopback, частные сети, link-local
+// (в том числе адрес метаданных облака 169.254.169.254) и групповые адреса.
+// Иначе подписка позволяла бы обращаться от имени сервера к службам внутренней сети.
+// Адрес проверяется при создании подписки и ещё раз при соединении, потому что
+// имя хоста может начать указывать на другой адрес уже после проверки.
+type Guard struct {
+	Allow []netip.Prefix // подсети, доставка в которые разрешена несмотря на запрет
+}
+
+// ParseNetworks разбирает список подсетей через запятую. Отдельный адрес
+// считается подсетью из одного адреса.
+func ParseNetworks(s string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(item); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		network, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook network %q", item)
+		}
+		networks = append(networks, network.Masked())
+	}
+	return networks, nil
+}
+
+// Allowed проверяет, можно ли доставлять события на адрес addr
+func (g Guard) Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, network := range g.Allow {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	for _, network := range blocked {
+		if network.Contains(addr) {
+			return false
+		}
+	}
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// CheckHost проверяет все адреса хоста host (имени или IP-адреса)
+func (g Guard) CheckHost(ctx context.Context, host string) error {
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return err
+		}
+	}
+	for _, addr := range addrs {
+		if !g.Allowed(addr) {
+			return fmt.Errorf("%w: %s", ErrPrivateAddress, addr)
+		}
+	}
+	return nil
+}
+
+// control проверяет адрес перед соединением (net.Dialer.Control)
+func (g Guard) control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !g.Allowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
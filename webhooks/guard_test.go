@@ -0,0 +1,52 @@
+package webhooks
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuard(t *testing.T) {
+	var g Guard
+	for _, addr := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "0.1.2.3", "224.0.0.1", "::1", "::", "fe80::1", "fd00::1",
+		"::ffff:127.0.0.1",
+	} {
+		assert.False(t, g.Allowed(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{"8.8.8.8", "93.184.216.34", "2001:4860:4860::8888"} {
+		assert.True(t, g.Allowed(netip.MustParseAddr(addr)), addr)
+	}
+
+	assert.ErrorIs(t, g.CheckHost(context.Background(), "127.0.0.1"), ErrPrivateAddress)
+	assert.ErrorIs(t, g.CheckHost(context.Background(), "::1"), ErrPrivateAddress)
+	assert.NoError(t, g.CheckHost(context.Background(), "8.8.8.8"))
+
+	allowed, err := ParseNetworks(" 10.0.0.0/8, 127.0.0.1 ,")
+	require.NoError(t, err)
+	g = Guard{Allow: allowed}
+	assert.True(t, g.Allowed(netip.MustParseAddr("10.20.30.40")))
+	assert.True(t, g.Allowed(netip.MustParseAddr("127.0.0.1")))
+	assert.False(t, g.Allowed(netip.MustParseAddr("127.0.0.2")))
+	assert.False(t, g.Allowed(netip.MustParseAddr("192.168.1.1")))
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks("")
+	assert.NoError(t, err)
+	assert.Empty(t, networks)
+
+	networks, err = ParseNetworks("192.168.1.7/24,::1")
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.168.1.0/24"),
+		netip.MustParsePrefix("::1/128"),
+	}, networks)
+
+	_, err = ParseNetworks("10.0.0.0/8,localhost")
+	assert.ErrorContains(t, err, `invalid webhook network "localhost"`)
+}
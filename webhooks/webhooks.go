@@ -0,0 +1,246 @@
+// Package webhooks доставляет события о задачах на URL подписчиков.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go_final_project/db"
+	"go_final_project/events"
+	"go_final_project/metrics"
+	"go_final_project/models"
+)
+
+// Заголовки запроса с событием
+const (
+	HeaderEvent     = "X-Todo-Event"
+	HeaderDelivery  = "X-Todo-Delivery"
+	HeaderSignature = "X-Todo-Signature"
+)
+
+// Значения по умолчанию для Dispatcher
+const (
+	DefaultMaxAttempts = 8
+	DefaultBaseDelay   = 30 * time.Second
+	DefaultMaxDelay    = time.Hour
+	DefaultInterval    = 10 * time.Second
+	DefaultWorkers     = 4
+
+	requestTimeout = 10 * time.Second
+	batchSize      = 100
+)
+
+// Dispatcher ставит события в очередь доставки и отправляет их подписчикам.
+// События сохраняются в базу в обработчике запроса, до ответа клиенту, а отправляются
+// отдельной горутиной Run, поэтому медленный подписчик не задерживает запросы
+// и события не теряются при перезапуске.
+// Неудачная доставка повторяется с экспоненциально растущей задержкой,
+// после MaxAttempts попыток доставка попадает в список недоставленных.
+type Dispatcher struct {
+	Store       db.WebhookStore
+	Guard       Guard         // запрет доставки во внутреннюю сеть для клиента по умолчанию
+	Client      *http.Client  // nil - клиент с таймаутом requestTimeout и проверкой Guard
+	MaxAttempts int           // число попыток, 0 - DefaultMaxAttempts
+	BaseDelay   time.Duration // задержка перед второй попыткой, 0 - DefaultBaseDelay
+	MaxDelay    time.Duration // наибольшая задержка, 0 - DefaultMaxDelay
+	Interval    time.Duration // как часто проверять очередь, 0 - DefaultInterval
+	Workers     int           // сколько доставок отправлять одновременно, 0 - DefaultWorkers
+
+	init   sync.Once
+	wake   chan struct{} // сигнал о новых событиях в очереди
+	client *http.Client
+}
+
+// Sign возвращает подпись тела запроса в формате заголовка X-Todo-Signature:
+// sha256=<HMAC-SHA256 тела с ключом подписки в hex>.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run отправляет доставки из очереди до отмены ctx. Очередь проверяется каждые Interval
+// и сразу после Enqueue; доставки, не отправленные до остановки, отправятся после перезапуска.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.setup()
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		d.Deliver(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enqueue сохраняет событие в очередь доставки подписчикам пользователя
+// и будит Run. Вызывается в обработчике запроса, изменившего задачу.
+func (d *Dispatcher) Enqueue(ctx context.Context, event events.Event) {
+	d.setup()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode event", "event", event.Type, "error", err)
+		return
+	}
+	n, err := d.Store.EnqueueDeliveries(ctx, event.UserID, event.Type, payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to enqueue event", "event", event.Type, "error", err)
+		return
+	}
+	if n > 0 {
+		select {
+		case d.wake <- struct{}{}:
+		default: // Run уже разбужен
+		}
+	}
+}
+
+// Deliver отправляет доставки, время попытки которых наступило к моменту now.
+// Запросы выполняются параллельно, не больше Workers одновременно,
+// чтобы один медленный подписчик не задерживал остальных.
+func (d *Dispatcher) Deliver(ctx context.Context, now time.Time) {
+	d.setup()
+	deliveries, err := d.Store.DueDeliveries(ctx, now, batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get due deliveries", "error", err)
+		metrics.JobRun("webhooks", metrics.OutcomeError)
+		return
+	}
+
+	results := make([]error, len(deliveries))
+	slots := make(chan struct{}, d.workers())
+	var wg sync.WaitGroup
+	for i, delivery := range deliveries {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			results[i] = d.send(ctx, delivery)
+			<-slots
+		}()
+	}
+	wg.Wait()
+
+	for i, delivery := range deliveries {
+		err := results[i]
+		// Попытка, прерванная остановкой сервера, не считается: доставка повторится после запуска
+		if err != nil && ctx.Err() != nil {
+			continue
+		}
+		delivery.Attempts++
+		switch {
+		case err == nil:
+			delivery.Status = models.DeliveryDelivered
+			delivery.LastError = ""
+			metrics.JobRun("webhooks", metrics.OutcomeSuccess)
+		case delivery.Attempts >= d.maxAttempts():
+			delivery.Status = models.DeliveryDead
+			delivery.LastError = err.Error()
+			slog.WarnContext(ctx, "webhook delivery failed permanently", "delivery_id", delivery.ID,
+				"event", delivery.Event, "url", delivery.URL, "attempts", delivery.Attempts, "error", err)
+			metrics.JobRun("webhooks", metrics.OutcomeDead)
+		default:
+			delivery.LastError = err.Error()
+			delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts)).UTC().Format(time.RFC3339)
+			slog.WarnContext(ctx, "webhook delivery failed", "delivery_id", delivery.ID,
+				"event", delivery.Event, "url", delivery.URL, "attempts", delivery.Attempts, "error", err)
+			metrics.JobRun("webhooks", metrics.OutcomeRetry)
+		}
+
+		// Результат сохраняется и при остановке сервера, иначе доставленное событие уйдёт повторно
+		if err := d.Store.UpdateDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+			slog.ErrorContext(ctx, "failed to update delivery", "delivery_id", delivery.ID, "error", err)
+		}
+	}
+}
+
+// setup создаёт канал пробуждения и клиент по умолчанию
+func (d *Dispatcher) setup() {
+	d.init.Do(func() {
+		d.wake = make(chan struct{}, 1)
+		d.client = d.Client
+		if d.client == nil {
+			dialer := &net.Dialer{Timeout: requestTimeout, Control: d.Guard.control}
+			d.client = &http.Client{
+				Timeout:   requestTimeout,
+				Transport: &http.Transport{DialContext: dialer.DialContext},
+			}
+		}
+	})
+}
+
+// send отправляет событие и ждёт ответа 2xx
+func (d *Dispatcher) send(ctx context.Context, delivery models.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// backoff возвращает задержку перед попыткой после attempts неудачных:
+// BaseDelay, 2*BaseDelay, 4*BaseDelay и т.д., но не больше MaxDelay.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	base, limit := d.BaseDelay, d.MaxDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	if limit <= 0 {
+		limit = DefaultMaxDelay
+	}
+
+	delay := base
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+func (d *Dispatcher) workers() int {
+	if d.Workers <= 0 {
+		return DefaultWorkers
+	}
+	return d.Workers
+}
+
+func (d *Dispatcher) maxAttempts() int {
+	if d.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return d.MaxAttempts
+}
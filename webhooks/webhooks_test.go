@@ -0,0 +1,248 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/db"
+	"go_final_project/events"
+	"go_final_project/models"
+)
+
+// local разрешает доставку на тестовые серверы httptest
+var local = Guard{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}
+
+// memoryDeliveries хранит очередь доставки в памяти
+type memoryDeliveries struct {
+	db.WebhookStore
+	mu         sync.Mutex
+	url        string // адрес подписки для новых доставок
+	deliveries []models.WebhookDelivery
+}
+
+func (m *memoryDeliveries) EnqueueDeliveries(ctx context.Context, userID int64, event string, payload []byte) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, models.WebhookDelivery{
+		ID:            strconv.Itoa(len(m.deliveries) + 1),
+		URL:           m.url,
+		Event:         event,
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now().UTC().Format(time.RFC3339),
+	})
+	return 1, nil
+}
+
+func (m *memoryDeliveries) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []models.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.Status == models.DeliveryPending && d.NextAttemptAt <= now.UTC().Format(time.RFC3339) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryDeliveries) UpdateDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == delivery.ID {
+			m.deliveries[i] = delivery
+		}
+	}
+	return nil
+}
+
+func TestSign(t *testing.T) {
+	// Пример из документации HMAC-SHA256 (RFC 4231, тест 2)
+	assert.Equal(t,
+		"sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		Sign("Jefe", []byte("what do ya want for nothing?")))
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 8*time.Second, d.backoff(4))
+	assert.Equal(t, 10*time.Second, d.backoff(5))
+	assert.Equal(t, 10*time.Second, d.backoff(50))
+}
+
+func TestDeliver(t *testing.T) {
+	var failing bool
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	store := &memoryDeliveries{deliveries: []models.WebhookDelivery{{
+		ID:            "1",
+		URL:           server.URL,
+		Secret:        "secret",
+		Event:         "task.created",
+		Payload:       []byte(`{"event":"task.created"}`),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: now.Format(time.RFC3339),
+	}}}
+	d := &Dispatcher{Store: store, Guard: local, MaxAttempts: 2, BaseDelay: time.Minute}
+
+	// Первая попытка неудачна, следующая назначается через BaseDelay
+	failing = true
+	d.Deliver(context.Background(), now)
+	delivery := store.deliveries[0]
+	require.NotNil(t, got)
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, now.Add(time.Minute).Format(time.RFC3339), delivery.NextAttemptAt)
+	assert.Contains(t, delivery.LastError, "503")
+
+	// До наступления времени попытки запрос не отправляется
+	got = nil
+	d.Deliver(context.Background(), now.Add(30*time.Second))
+	assert.Nil(t, got)
+
+	// Вторая попытка успешна
+	failing = false
+	d.Deliver(context.Background(), now.Add(time.Minute))
+	require.NotNil(t, got)
+	assert.Equal(t, "task.created", got.Header.Get(HeaderEvent))
+	assert.Equal(t, "1", got.Header.Get(HeaderDelivery))
+	assert.Equal(t, Sign("secret", body), got.Header.Get(HeaderSignature))
+	assert.JSONEq(t, `{"event":"task.created"}`, string(body))
+	assert.Equal(t, models.DeliveryDelivered, store.deliveries[0].Status)
+	assert.Equal(t, 2, store.deliveries[0].Attempts)
+}
+
+func TestDeliverDead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	store := &memoryDeliveries{deliveries: []models.WebhookDelivery{{
+		ID:            "1",
+		URL:           server.URL,
+		Event:         "task.done",
+		Payload:       []byte(`{}`),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: now.Format(time.RFC3339),
+	}}}
+	d := &Dispatcher{Store: store, Guard: local, MaxAttempts: 2, BaseDelay: time.Minute}
+
+	d.Deliver(context.Background(), now)
+	d.Deliver(context.Background(), now.Add(time.Hour))
+	assert.Equal(t, models.DeliveryDead, store.deliveries[0].Status)
+	assert.Equal(t, 2, store.deliveries[0].Attempts)
+
+	// Недоставленные больше не отправляются
+	d.Deliver(context.Background(), now.Add(2*time.Hour))
+	assert.Equal(t, 2, store.deliveries[0].Attempts)
+}
+
+func TestDeliverCancelled(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Контекст запроса отменяется при обрыве соединения, только когда тело прочитано
+		io.ReadAll(r.Body)
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	store := &memoryDeliveries{deliveries: []models.WebhookDelivery{{
+		ID:            "1",
+		URL:           server.URL,
+		Event:         "task.done",
+		Payload:       []byte(`{}`),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: now.Format(time.RFC3339),
+	}}}
+	d := &Dispatcher{Store: store, Guard: local, MaxAttempts: 1}
+
+	// Остановка сервера посреди запроса не расходует попытки и не отправляет событие в недоставленные
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	d.Deliver(ctx, now)
+	assert.Equal(t, models.DeliveryPending, store.deliveries[0].Status)
+	assert.Zero(t, store.deliveries[0].Attempts)
+	assert.Empty(t, store.deliveries[0].LastError)
+}
+
+func TestRunDeliversEnqueued(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(HeaderEvent)
+	}))
+	defer server.Close()
+
+	// Очередь проверяется раз в час, поэтому доставка сразу возможна только после Enqueue
+	store := &memoryDeliveries{url: server.URL}
+	d := &Dispatcher{Store: store, Guard: local, Interval: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+
+	d.Enqueue(context.Background(), events.New(events.TaskDone, 1, models.Task{ID: "7"}))
+	select {
+	case event := <-received:
+		assert.Equal(t, events.TaskDone, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("событие не доставлено")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestDeliverPrivate(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	store := &memoryDeliveries{deliveries: []models.WebhookDelivery{{
+		ID:            "1",
+		URL:           server.URL,
+		Event:         "task.done",
+		Payload:       []byte(`{}`),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: now.Format(time.RFC3339),
+	}}}
+	d := &Dispatcher{Store: store}
+
+	d.Deliver(context.Background(), now)
+	assert.False(t, called)
+	assert.Contains(t, store.deliveries[0].LastError, ErrPrivateAddress.Error())
+}